@@ -1,6 +1,9 @@
 package stacktrace
 
-import "runtime"
+import (
+	"runtime"
+	"sync"
+)
 
 // Callers returns the program counters (PCs) of function invocations on the
 // calling goroutine's stack, skipping the specified number of stack frames.
@@ -10,21 +13,66 @@ import "runtime"
 //
 // The returned slice contains the collected program counters, which can be
 // further processed using runtime.CallersFrames to obtain function details.
+//
+// The stack is walked into a pooled scratch buffer, and the result is a single
+// copy of the exact size, so Callers allocates only the returned slice.
 func Callers(skip int) []uintptr {
-	skip += 2
-	const size = 16
-	var pc []uintptr
+	return AppendCallers(nil, skip+1)
+}
+
+// AppendCallers appends the program counters (PCs) of function invocations on
+// the calling goroutine's stack to dst, skipping the specified number of stack
+// frames in the same way as [Callers] does, and returns the extended slice.
+//
+// A skip value of 0 starts from the caller of AppendCallers itself.
+//
+// AppendCallers doesn't allocate if dst has enough capacity. Otherwise, it
+// allocates a new slice whose capacity is exactly the length of the result.
+func AppendCallers(dst []uintptr, skip int) []uintptr {
+	bp := callersPool.Get().(*[]uintptr)
 	for {
-		x := make([]uintptr, size)
-		n := runtime.Callers(skip, x)
-		if n != len(x) {
-			pc = append(pc, x[:n]...)
+		n := runtime.Callers(skip+2, *bp)
+		if n < len(*bp) {
+			dst = appendExact(dst, (*bp)[:n])
 			break
 		}
-		pc = append(pc, x...)
-		skip += n
+		*bp = make([]uintptr, 2*len(*bp))
 	}
-	return pc
+	if len(*bp) <= maxPooledCallers {
+		callersPool.Put(bp)
+	}
+	return dst
+}
+
+const (
+	// pooledCallers is the initial size of the pooled scratch buffers,
+	// which is enough for most stacks.
+	pooledCallers = 64
+
+	// maxPooledCallers is the maximum size of the pooled scratch buffers.
+	// The larger ones grown for deep stacks are discarded.
+	maxPooledCallers = 1024
+)
+
+// callersPool holds the scratch buffers of type *[]uintptr for
+// runtime.Callers.
+var callersPool = sync.Pool{
+	New: func() any {
+		buf := make([]uintptr, pooledCallers)
+		return &buf
+	},
+}
+
+// appendExact appends src to dst. If dst doesn't have enough capacity, the
+// capacity of the new slice is exactly the length of the result.
+func appendExact(dst, src []uintptr) []uintptr {
+	if len(src) == 0 || cap(dst)-len(dst) >= len(src) {
+		return append(dst, src...)
+	}
+	s := make([]uintptr, len(dst)+len(src))
+	copy(s, dst)
+	copy(s[len(dst):], src)
+	return s
 }
 
 // CallersLimit returns the program counters (PCs) of function invocations on the
@@ -41,6 +89,7 @@ func Callers(skip int) []uintptr {
 //
 // The returned slice contains the collected program counters, which can be
 // further processed using runtime.CallerFrames to obtain function details.
+// Its capacity is the number of them, even if it is less than limit.
 func CallersLimit(skip, limit int) []uintptr {
 	switch {
 	case limit == 0:
@@ -48,7 +97,27 @@ func CallersLimit(skip, limit int) []uintptr {
 	case limit < 0:
 		return Callers(skip + 1)
 	}
-	pc := make([]uintptr, limit)
-	n := runtime.Callers(skip+2, pc)
-	return pc[:n]
+	bp := callersPool.Get().(*[]uintptr)
+	var pc []uintptr
+	for {
+		buf := *bp
+		if len(buf) > limit {
+			buf = buf[:limit]
+		}
+		n := runtime.Callers(skip+2, buf)
+		if n < len(buf) || len(buf) == limit {
+			pc = appendExact(nil, buf[:n])
+			break
+		}
+		// The scratch buffer is grown up to limit only for deep stacks.
+		size := 2 * len(*bp)
+		if size > limit {
+			size = limit
+		}
+		*bp = make([]uintptr, size)
+	}
+	if len(*bp) <= maxPooledCallers {
+		callersPool.Put(bp)
+	}
+	return pc
 }
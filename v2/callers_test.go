@@ -1,6 +1,9 @@
 package stacktrace_test
 
 import (
+	"fmt"
+	"os"
+	"reflect"
 	"runtime"
 	"testing"
 
@@ -42,6 +45,7 @@ func TestCallersLimit(t *testing.T) {
 		{name: "Skip 0, Limit 99", skip: 0, limit: 99, expectLen: -1},
 		{name: "Skip 1, Limit 99", skip: 1, limit: 99, expectLen: -1},
 		{name: "Skip 2, Limit 99", skip: 2, limit: 99, expectLen: -1},
+		{name: "Skip 0, Limit 5000", skip: 0, limit: 5000, expectLen: -1},
 		{name: "Skip 0, Limit -1", skip: 0, limit: -1, expectLen: -1}, // Should return all frames after skip + 1
 		{name: "Skip 1, Limit -1", skip: 1, limit: -1, expectLen: -1}, // Should return all frames after skip + 1
 	}
@@ -57,6 +61,110 @@ func TestCallersLimit(t *testing.T) {
 			if len(result) != tc.expectLen {
 				t.Errorf("Expected length %d, got %d", tc.expectLen, len(result))
 			}
+			if cap(result) != len(result) {
+				t.Errorf("Expected capacity %d, got %d", len(result), cap(result))
+			}
 		})
 	}
 }
+
+// atDepth calls fn at the stack depth of at least depth frames.
+//
+//go:noinline
+func atDepth(depth int, fn func()) {
+	if depth <= 0 {
+		fn()
+		return
+	}
+	atDepth(depth-1, fn)
+}
+
+func TestAppendCallers(t *testing.T) {
+	for _, depth := range []int{0, 100, 2000} {
+		t.Run(fmt.Sprint(depth), func(t *testing.T) {
+			atDepth(depth, func() {
+				want := stacktrace.Callers(0)
+				got := stacktrace.AppendCallers([]uintptr{1, 2}, 0)
+				if len(got) != 2+len(want) || !reflect.DeepEqual(got[:2], []uintptr{1, 2}) {
+					t.Fatalf("len(got) = %d, len(want) = %d", len(got), len(want))
+				}
+				// The first entries differ because they are the different lines.
+				if !reflect.DeepEqual(got[3:], want[1:]) {
+					t.Errorf("got=%v want=%v", got[3:], want[1:])
+				}
+				if cap(want) != len(want) {
+					t.Errorf("cap(want) = %d, must be %d", cap(want), len(want))
+				}
+			})
+		})
+	}
+
+	t.Run("capacity", func(t *testing.T) {
+		if raceEnabled {
+			t.Skip("sync.Pool drops the pooled values randomly with the race detector")
+		}
+		dst := make([]uintptr, 0, 256)
+		allocs := testing.AllocsPerRun(100, func() {
+			dst = stacktrace.AppendCallers(dst[:0], 0)
+		})
+		if allocs != 0 {
+			t.Errorf("allocs = %v, must be 0", allocs)
+		}
+	})
+}
+
+// TestCapture_allocs verifies the number of the allocations to capture the
+// stacks: the Error with its symbols and the callers of the exact size.
+func TestCapture_allocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("sync.Pool drops the pooled values randomly with the race detector")
+	}
+	tests := []struct {
+		name   string
+		fn     func()
+		allocs float64
+	}{
+		{"Callers", func() { _ = stacktrace.Callers(0) }, 1},
+		{"CallersLimit", func() { _ = stacktrace.CallersLimit(0, 1000) }, 1},
+		{"CallersLimit above the pooled size", func() { _ = stacktrace.CallersLimit(0, 5000) }, 1},
+		{"Trace", func() { _ = stacktrace.Trace(os.ErrInvalid) }, 2},
+		{"New", func() { _ = stacktrace.New("x") }, 3},
+	}
+	for _, tt := range tests {
+		for _, depth := range []int{0, 50, 200} {
+			t.Run(fmt.Sprintf("%s/%d", tt.name, depth), func(t *testing.T) {
+				var allocs float64
+				atDepth(depth, func() {
+					allocs = testing.AllocsPerRun(100, tt.fn)
+				})
+				// A GC may empty the pool of the scratch buffers.
+				if allocs < tt.allocs || allocs > tt.allocs+0.1 {
+					t.Errorf("allocs = %v, must be %v", allocs, tt.allocs)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkCapture(b *testing.B) {
+	fns := []struct {
+		name string
+		fn   func() error
+	}{
+		{"Trace", func() error { return stacktrace.Trace(os.ErrInvalid) }},
+		{"New", func() error { return stacktrace.New("x") }},
+		{"Errorf", func() error { return stacktrace.Errorf("x: %w", os.ErrInvalid) }},
+	}
+	for _, f := range fns {
+		for _, depth := range []int{0, 16, 64, 256} {
+			b.Run(fmt.Sprintf("%s/depth=%d", f.name, depth), func(b *testing.B) {
+				atDepth(depth, func() {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						_ = f.fn()
+					}
+				})
+			})
+		}
+	}
+}
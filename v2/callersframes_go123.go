@@ -27,3 +27,18 @@ func CallersFrames(callers []uintptr) iter.Seq[*runtime.Frame] {
 		}
 	}
 }
+
+// CallersFrames returns an iterator sequence of *[runtime.Frame] for err.Callers,
+// in the same way as the [CallersFrames] function does.
+//
+// The frames are resolved at most once and shared with [Error.Error] and
+// [GetDebugInfo]. Each yielded frame is a copy, so it may be modified freely.
+func (err Error) CallersFrames() iter.Seq[*runtime.Frame] {
+	return func(yield func(*runtime.Frame) bool) {
+		for _, frame := range err.resolvedFrames() {
+			if !yield(&frame) {
+				break
+			}
+		}
+	}
+}
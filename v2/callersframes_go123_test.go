@@ -56,3 +56,17 @@ func funcnameOf(t *testing.T, pc uintptr) string {
 	}
 	return f.Name()
 }
+
+func TestError_CallersFrames(t *testing.T) {
+	err := stacktrace.NewError(nil, stacktrace.Callers(0))
+	var got, want []string
+	for frame := range err.CallersFrames() {
+		got = append(got, frame.Function)
+	}
+	for frame := range stacktrace.CallersFrames(err.Callers) {
+		want = append(want, frame.Function)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got=%v want=%v", got, want)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+)
+
+func runDiff(cmd *command, flags *flag.FlagSet, args []string) error {
+	color := colorFlag("auto")
+	flags.Var(&color, "color", "colorize the output: auto, always or never")
+	if err := cmd.parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return errUsage
+	}
+	var pair [2]trace
+	for i, name := range flags.Args() {
+		traces, err := cmd.readTraces([]string{name})
+		if err != nil {
+			return err
+		}
+		if len(traces) == 0 {
+			return fmt.Errorf("%s: no trace found", name)
+		}
+		pair[i] = traces[0]
+	}
+	w := bufio.NewWriter(cmd.stdout)
+	p := &printer{w: w, color: color.enabled(cmd.stdout)}
+	p.printDiff(flags.Arg(0), flags.Arg(1), pair[0], pair[1])
+	return w.Flush()
+}
+
+// printDiff prints the difference between the traces a and b as a unified
+// diff of their details and stack entries.
+func (p *printer) printDiff(nameA, nameB string, a, b trace) {
+	fmt.Fprintln(p.w, p.paint(ansiRed, "--- "+nameA))
+	fmt.Fprintln(p.w, p.paint(ansiGreen, "+++ "+nameB))
+	linesA := append([]string{a.Detail}, a.Entries...)
+	linesB := append([]string{b.Detail}, b.Entries...)
+	for _, op := range diffLines(linesA, linesB) {
+		switch op.kind {
+		case '-':
+			fmt.Fprintln(p.w, p.paint(ansiRed, "-"+op.text))
+		case '+':
+			fmt.Fprintln(p.w, p.paint(ansiGreen, "+"+op.text))
+		default:
+			fmt.Fprintln(p.w, " "+op.text)
+		}
+	}
+}
+
+// diffOp is an operation of a line diff.
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	text string
+}
+
+// diffLines returns the operations that transform a into b, computed from the
+// longest common subsequence of the lines.
+func diffLines(a, b []string) []diffOp {
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// trace is a stack trace extracted from a log record.
+type trace struct {
+	Detail  string
+	Entries []string
+}
+
+// readTraces reads JSON lines from r and returns the traces found in them.
+// The lines that are not JSON objects are ignored.
+//
+// If keyPath is not empty, it is a dot-separated path to the value in each
+// record to look for the traces. Otherwise, the whole record is searched.
+func readTraces(r io.Reader, keyPath string) ([]trace, error) {
+	var list []trace
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var v any
+		if err := json.Unmarshal([]byte(line), &v); err != nil {
+			continue
+		}
+		if keyPath != "" {
+			var ok bool
+			if v, ok = lookup(v, keyPath); !ok {
+				continue
+			}
+		}
+		list = appendTraces(list, v)
+	}
+	return list, s.Err()
+}
+
+// lookup returns the value at the dot-separated keyPath in v.
+func lookup(v any, keyPath string) (any, bool) {
+	for _, key := range strings.Split(keyPath, ".") {
+		m, ok := v.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if v, ok = m[key]; !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// appendTraces appends the traces found in v to list.
+//
+// The following structures are recognized:
+//
+//   - stacktrace/v2.DebugInfo: {"detail": "...", "stack_entries": ["..."]}
+//   - stacktrace.StackDump of v1: {"error": "...", "traces": [{"detail": "...", "stack_entries": ["..."]}]}
+//   - stacktrace/v2.DebugInfo logged by log/slog: {"msg": "...", "frames": {"0": "...", ...}}
+//   - stacktrace/v2.Error logged by log/slog: {"msg": "...", "frames": {"0": {"function": "...", "file": "...", "line": 1}, ...}, "traces": {"0": {...}, ...}}
+//   - a record with the stack attribute added by stacktrace/v2/slogtrace: {"msg": "...", "stack": ["..."]}
+func appendTraces(list []trace, v any) []trace {
+	switch v := v.(type) {
+	case map[string]any:
+		if t, ok := asSlogtraceRecord(v); ok {
+			// The error in the record, if any, has the same trace.
+			return append(list, t)
+		}
+		if t, ok := asDebugInfo(v); ok {
+			return append(list, t)
+		}
+		if t, ok := asStackDump(v); ok {
+			return append(list, t)
+		}
+		if t, ok := asSlogDebugInfo(v); ok {
+			return append(list, t)
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			list = appendTraces(list, v[key])
+		}
+	case []any:
+		for _, e := range v {
+			list = appendTraces(list, e)
+		}
+	}
+	return list
+}
+
+func asDebugInfo(m map[string]any) (trace, bool) {
+	entries, ok := asStrings(m["stack_entries"])
+	if !ok {
+		return trace{}, false
+	}
+	detail, _ := m["detail"].(string)
+	return trace{Detail: detail, Entries: entries}, true
+}
+
+func asStackDump(m map[string]any) (trace, bool) {
+	traces, ok := m["traces"].([]any)
+	if !ok {
+		return trace{}, false
+	}
+	t := trace{}
+	t.Detail, _ = m["error"].(string)
+	for i, v := range traces {
+		tm, ok := v.(map[string]any)
+		if !ok {
+			return trace{}, false
+		}
+		sub, ok := asDebugInfo(tm)
+		if !ok {
+			return trace{}, false
+		}
+		if i != 0 || sub.Detail != t.Detail {
+			t.Entries = append(t.Entries, "## "+sub.Detail)
+		}
+		t.Entries = append(t.Entries, sub.Entries...)
+	}
+	return t, true
+}
+
+// asSlogDebugInfo recognizes a DebugInfo or an Error logged by log/slog.
+func asSlogDebugInfo(m map[string]any) (trace, bool) {
+	detail, ok := m["msg"].(string)
+	if !ok {
+		return trace{}, false
+	}
+	for key := range m {
+		switch key {
+		case "msg", "frames", "truncated", "traces":
+		default:
+			return trace{}, false
+		}
+	}
+	entries, ok := asSlogFrames(m["frames"])
+	if !ok {
+		return trace{}, false
+	}
+	if truncated, _ := m["truncated"].(bool); truncated {
+		entries = append(entries, truncatedEntry)
+	}
+	t := trace{Detail: detail, Entries: entries}
+	if traces, ok := m["traces"]; ok {
+		list, ok := asSlogGroup(traces)
+		if !ok {
+			return trace{}, false
+		}
+		for _, v := range list {
+			sub, ok := v.(map[string]any)
+			if !ok {
+				return trace{}, false
+			}
+			st, ok := asSlogDebugInfo(sub)
+			if !ok {
+				return trace{}, false
+			}
+			t.Entries = append(t.Entries, "## "+st.Detail)
+			t.Entries = append(t.Entries, st.Entries...)
+		}
+	}
+	return t, true
+}
+
+// truncatedEntry is the entry of GetDebugInfo for a stack truncated by the
+// sampling.
+const truncatedEntry = "... stack truncated by sampling"
+
+// asSlogFrames returns the entries of the frames group logged by log/slog,
+// whose values are either the entries or the Frames.
+func asSlogFrames(v any) ([]string, bool) {
+	list, ok := asSlogGroup(v)
+	if !ok {
+		return nil, false
+	}
+	entries := make([]string, len(list))
+	for i, v := range list {
+		switch v := v.(type) {
+		case string:
+			entries[i] = v
+		case map[string]any:
+			function, ok1 := v["function"].(string)
+			file, ok2 := v["file"].(string)
+			line, ok3 := v["line"].(float64)
+			if !ok1 || !ok2 || !ok3 {
+				return nil, false
+			}
+			entries[i] = file + ":" + strconv.Itoa(int(line)) + " " + shortFunction(function)
+		default:
+			return nil, false
+		}
+	}
+	return entries, true
+}
+
+// asSlogGroup returns the values of a group logged by log/slog whose keys are
+// the indexes, in the order of the indexes.
+func asSlogGroup(v any) ([]any, bool) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	list := make([]any, len(m))
+	for key, v := range m {
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || len(list) <= i {
+			return nil, false
+		}
+		list[i] = v
+	}
+	return list, true
+}
+
+// asSlogtraceRecord recognizes a record with the stack attribute added by
+// slogtrace, whose trace has the message of the record as the detail.
+func asSlogtraceRecord(m map[string]any) (trace, bool) {
+	entries, ok := asStrings(m["stack"])
+	if !ok {
+		return trace{}, false
+	}
+	detail, _ := m["msg"].(string)
+	return trace{Detail: detail, Entries: entries}, true
+}
+
+// shortFunction returns the function name without the package path if it
+// contains a slash, in the same way as the entries of GetDebugInfo,
+// e.g. "(*Error).Error" for "github.com/goaux/stacktrace/v2.(*Error).Error".
+func shortFunction(s string) string {
+	if i := strings.LastIndexByte(s, '/'); i != -1 {
+		if j := strings.IndexByte(s[i+1:], '.'); j != -1 {
+			return s[i+j+2:]
+		}
+	}
+	return s
+}
+
+func asStrings(v any) ([]string, bool) {
+	list, ok := v.([]any)
+	if !ok {
+		return nil, false
+	}
+	s := make([]string, len(list))
+	for i, e := range list {
+		if s[i], ok = e.(string); !ok {
+			return nil, false
+		}
+	}
+	return s, true
+}
+
+// entry is a parsed stack entry.
+type entry struct {
+	// Heading is the message of a "## message" entry.
+	Heading string
+
+	// File, Line and Function are set for the "<file>:<line> <function>" entries.
+	File     string
+	Line     int
+	Function string
+
+	// Text is the entry as is.
+	Text string
+}
+
+func (e entry) isFrame() bool {
+	return e.File != ""
+}
+
+// site returns the code location of the frame, which doesn't depend on the
+// directory where the program was built.
+func (e entry) site() string {
+	return path.Base(e.File) + ":" + strconv.Itoa(e.Line) + " " + e.Function
+}
+
+func parseEntry(s string) entry {
+	if heading, ok := strings.CutPrefix(s, "## "); ok {
+		return entry{Heading: heading, Text: s}
+	}
+	loc, function, ok := strings.Cut(s, " ")
+	if !ok {
+		return entry{Text: s}
+	}
+	i := strings.LastIndexByte(loc, ':')
+	if i <= 0 {
+		return entry{Text: s}
+	}
+	line, err := strconv.Atoi(loc[i+1:])
+	if err != nil {
+		return entry{Text: s}
+	}
+	return entry{File: loc[:i], Line: line, Function: function, Text: s}
+}
+
+// signature returns a hash of the code locations of the frames of t,
+// ignoring the messages and the directories of the files.
+//
+// Unlike stacktrace.Fingerprint, which hashes the fully qualified function
+// names of the errors in a process, it hashes the code locations as they
+// appear in the entries, so the two never match.
+func (t trace) signature() string {
+	h := sha256.New()
+	for _, s := range t.Entries {
+		e := parseEntry(s)
+		switch {
+		case e.isFrame():
+			io.WriteString(h, e.site()+"\n")
+		case e.Heading != "":
+			h.Write([]byte{0})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// topSite returns the code location of the first frame of t,
+// or an empty string if t has no frames.
+func (t trace) topSite() string {
+	for _, s := range t.Entries {
+		if e := parseEntry(s); e.isFrame() {
+			return e.site()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,155 @@
+// Command stacktrace pretty-prints and analyzes the stack traces in logs.
+//
+// Usage:
+//
+//	stacktrace [print] [flags] [file ...]
+//	stacktrace top [flags] [file ...]
+//	stacktrace diff [flags] file1 file2
+//
+// It reads JSON lines from the files, or from the standard input if no file is
+// given, and extracts the stack traces nested anywhere in the log records.
+// The following structures are recognized:
+//
+//   - [github.com/goaux/stacktrace/v2.DebugInfo] and [github.com/goaux/stacktrace/v2.Error] logged by log/slog
+//   - the stack attribute added by [github.com/goaux/stacktrace/v2/slogtrace]
+//   - [github.com/goaux/stacktrace.StackDump]
+//
+// The subcommands are:
+//
+//	print  pretty-print the traces with colors and source code context
+//	top    list the most frequent code locations where the errors occurred
+//	diff   compare the first traces of two files
+//
+// Run "stacktrace <subcommand> -h" for the flags of each subcommand.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(cmd *command, flags *flag.FlagSet, args []string) error
+
+	stdin          io.Reader
+	stdout, stderr io.Writer
+	keyPath        string
+}
+
+var commands = []*command{
+	{name: "print", usage: "[flags] [file ...]", run: runPrint},
+	{name: "top", usage: "[flags] [file ...]", run: runTop},
+	{name: "diff", usage: "[flags] file1 file2", run: runDiff},
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	cmd := commands[0]
+	if len(args) != 0 {
+		for _, c := range commands {
+			if args[0] == c.name {
+				cmd, args = c, args[1:]
+				break
+			}
+		}
+	}
+	cmd.stdin, cmd.stdout, cmd.stderr = stdin, stdout, stderr
+	flags := flag.NewFlagSet("stacktrace "+cmd.name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&cmd.keyPath, "path", "", "dot-separated `path` to the trace in each record (default: search the whole record)")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: stacktrace %s %s\n", cmd.name, cmd.usage)
+		flags.PrintDefaults()
+	}
+	if err := cmd.run(cmd, flags, args); err != nil {
+		switch err {
+		case flag.ErrHelp:
+			return 0
+		case errUsage:
+			return 2
+		}
+		fmt.Fprintln(stderr, "stacktrace:", err)
+		return 1
+	}
+	return 0
+}
+
+// errUsage reports that the command line is invalid and the usage has been
+// printed.
+var errUsage = errors.New("usage")
+
+// parse parses the flags and handles the usage errors.
+func (cmd *command) parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	return nil
+}
+
+// readTraces reads the traces from the named files, or from the standard
+// input if names is empty.
+func (cmd *command) readTraces(names []string) ([]trace, error) {
+	if len(names) == 0 {
+		return readTraces(cmd.stdin, cmd.keyPath)
+	}
+	var list []trace
+	for _, name := range names {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		traces, err := readTraces(f, cmd.keyPath)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		list = append(list, traces...)
+	}
+	return list, nil
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorFlag is the value of the -color flag.
+type colorFlag string
+
+func (c *colorFlag) String() string { return string(*c) }
+
+func (c *colorFlag) Set(s string) error {
+	switch s {
+	case "auto", "always", "never":
+		*c = colorFlag(s)
+		return nil
+	}
+	return fmt.Errorf("must be one of auto, always or never")
+}
+
+func (c colorFlag) enabled(w io.Writer) bool {
+	switch c {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	return isTerminal(w) && os.Getenv("NO_COLOR") == "" && !strings.EqualFold(os.Getenv("TERM"), "dumb")
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func runTest(t *testing.T, stdin string, args ...string) string {
+	t.Helper()
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	if status := run(args, strings.NewReader(stdin), stdout, stderr); status != 0 {
+		t.Fatalf("status=%d stderr=%s", status, stderr)
+	}
+	return stdout.String()
+}
+
+func TestPrint(t *testing.T) {
+	got := runTest(t, "", "print", "-color=never", "testdata/app.log")
+	want := `open a.txt: no such file or directory (run.go:10 main.run)
+    main.run
+      /build/app/run.go:10
+    main.main
+      /build/app/main.go:11
+
+open b.txt: no such file or directory (run.go:10 main.run)
+    main.run
+      /other/app/run.go:10
+    main.main
+      /other/app/main.go:11
+
+timeout
+    query
+      /build/app/db.go:20
+    main
+      /build/app/main.go:12
+
+denied (auth.go:5 main.auth)
+    main.auth
+      /build/app/auth.go:5
+    main.main
+      /build/app/main.go:13
+
+locked (lock.go:7 main.lock)
+    main.lock
+      /build/app/lock.go:7
+    main.main
+      /build/app/main.go:14
+  ## busy (wait.go:3 wait.Wait)
+    Wait
+      /build/app/internal/wait/wait.go:3
+    ... stack truncated by sampling
+
+quota check failed
+    main.check
+      /build/app/quota.go:9
+    main.main
+      /build/app/main.go:15
+
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	t.Run("default subcommand and stdin", func(t *testing.T) {
+		log, err := os.ReadFile("testdata/app.log")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := runTest(t, string(log), "-color=never"); got != want {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("path", func(t *testing.T) {
+		got := runTest(t, "", "print", "-color=never", "-path=dump", "testdata/app.log")
+		if !strings.HasPrefix(got, "timeout\n") || strings.Count(got, "\n\n") != 1 {
+			t.Errorf("got:\n%s", got)
+		}
+	})
+
+	t.Run("color", func(t *testing.T) {
+		got := runTest(t, "", "print", "-color=always", "testdata/app.log")
+		if !strings.Contains(got, ansiCyan+"main.run"+ansiReset) {
+			t.Errorf("got:\n%q", got)
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		got := runTest(t, "", "print", "-color=never", "-group", "testdata/app.log")
+		if !strings.HasPrefix(got, "2× signature ") || strings.Count(got, "× signature ") != 5 {
+			t.Errorf("got:\n%s", got)
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		dir := t.TempDir()
+		file := filepath.Join(dir, "x.go")
+		if err := os.WriteFile(file, []byte("package x\n\nfunc f() {\n\tpanic(1)\n}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		log := `{"detail":"d","stack_entries":["` + filepath.ToSlash(file) + `:4 x.f"]}`
+		got := runTest(t, log, "print", "-color=never", "-context=1")
+		want := "d\n    x.f\n      " + filepath.ToSlash(file) + ":4\n" +
+			"        3 | func f() {\n" +
+			"      > 4 | \tpanic(1)\n" +
+			"        5 | }\n\n"
+		if got != want {
+			t.Errorf("got:\n%q\nwant:\n%q", got, want)
+		}
+	})
+}
+
+func TestTop(t *testing.T) {
+	got := runTest(t, "", "top", "testdata/app.log")
+	want := `      2  run.go:10 main.run
+         open a.txt: no such file or directory (run.go:10 main.run)
+      1  db.go:20 query
+         timeout
+      1  auth.go:5 main.auth
+         denied (auth.go:5 main.auth)
+      1  lock.go:7 main.lock
+         locked (lock.go:7 main.lock)
+      1  quota.go:9 main.check
+         quota check failed
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	got = runTest(t, "", "top", "-n=1", "-by=signature", "testdata/app.log")
+	if strings.Count(got, "\n") != 2 || !strings.HasPrefix(got, "      2  ") {
+		t.Errorf("got:\n%s", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.log")
+	b := filepath.Join(dir, "b.log")
+	os.WriteFile(a, []byte(`{"detail":"x","stack_entries":["a.go:1 f","a.go:2 g","main.go:3 main.main"]}`), 0o644)
+	os.WriteFile(b, []byte(`{"detail":"y","stack_entries":["a.go:1 f","b.go:5 h","main.go:3 main.main"]}`), 0o644)
+	got := runTest(t, "", "diff", "-color=never", a, b)
+	want := "--- " + a + "\n+++ " + b + "\n-x\n+y\n a.go:1 f\n-a.go:2 g\n+b.go:5 h\n main.go:3 main.main\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRun_usage(t *testing.T) {
+	for _, args := range [][]string{
+		{"diff", "only-one"},
+		{"top", "-by=unknown"},
+		{"print", "-color=rainbow"},
+	} {
+		if status := run(args, strings.NewReader(""), new(bytes.Buffer), new(bytes.Buffer)); status != 2 {
+			t.Errorf("args=%q status=%d must be 2", args, status)
+		}
+	}
+	if status := run([]string{"print", "no/such/file"}, nil, new(bytes.Buffer), new(bytes.Buffer)); status != 1 {
+		t.Errorf("status=%d must be 1", status)
+	}
+}
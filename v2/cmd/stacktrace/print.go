@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiDim    = "\x1b[2m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiCyan   = "\x1b[36m"
+)
+
+// printer writes the traces in a human friendly form.
+type printer struct {
+	w       io.Writer
+	color   bool
+	context int
+
+	sources map[string][]string
+}
+
+func runPrint(cmd *command, flags *flag.FlagSet, args []string) error {
+	color := colorFlag("auto")
+	flags.Var(&color, "color", "colorize the output: auto, always or never")
+	context := flags.Int("context", 0, "print `n` lines of source code around the first frame of each stack, if the file is readable")
+	group := flags.Bool("group", false, "print each group of the traces with the same signature (the code locations of all frames) once, with the count")
+	if err := cmd.parse(flags, args); err != nil {
+		return err
+	}
+	traces, err := cmd.readTraces(flags.Args())
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(cmd.stdout)
+	p := &printer{w: w, color: color.enabled(cmd.stdout), context: *context}
+	if *group {
+		for _, g := range groupTraces(traces) {
+			p.printGroup(g)
+		}
+	} else {
+		for _, t := range traces {
+			p.printTrace(t)
+		}
+	}
+	return w.Flush()
+}
+
+func (p *printer) paint(code, s string) string {
+	if !p.color {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func (p *printer) printGroup(g traceGroup) {
+	fmt.Fprintf(p.w, "%s %s\n", p.paint(ansiGreen, strconv.Itoa(g.count)+"×"), p.paint(ansiDim, "signature "+g.signature))
+	p.printTrace(g.trace)
+}
+
+func (p *printer) printTrace(t trace) {
+	fmt.Fprintln(p.w, p.paint(ansiBold+ansiRed, t.Detail))
+	first := true
+	for _, s := range t.Entries {
+		e := parseEntry(s)
+		switch {
+		case e.Heading != "":
+			fmt.Fprintf(p.w, "  %s\n", p.paint(ansiYellow, e.Text))
+			first = true
+		case e.isFrame():
+			fmt.Fprintf(p.w, "    %s\n      %s\n",
+				p.paint(ansiCyan, e.Function),
+				p.paint(ansiDim, e.File+":"+strconv.Itoa(e.Line)),
+			)
+			if first {
+				p.printSource(e.File, e.Line)
+			}
+			first = false
+		default:
+			fmt.Fprintf(p.w, "    %s\n", p.paint(ansiDim, e.Text))
+		}
+	}
+	fmt.Fprintln(p.w)
+}
+
+// printSource prints the lines of the file around line, if the file is readable.
+func (p *printer) printSource(file string, line int) {
+	if p.context <= 0 {
+		return
+	}
+	lines := p.source(file)
+	if line < 1 || len(lines) < line {
+		return
+	}
+	from, to := line-p.context, line+p.context
+	if from < 1 {
+		from = 1
+	}
+	if len(lines) < to {
+		to = len(lines)
+	}
+	width := len(strconv.Itoa(to))
+	for n := from; n <= to; n++ {
+		text := fmt.Sprintf("%*d | %s", width, n, lines[n-1])
+		if n == line {
+			fmt.Fprintf(p.w, "      %s %s\n", p.paint(ansiRed, ">"), p.paint(ansiBold, text))
+		} else {
+			fmt.Fprintf(p.w, "        %s\n", p.paint(ansiDim, text))
+		}
+	}
+}
+
+func (p *printer) source(file string) []string {
+	if lines, ok := p.sources[file]; ok {
+		return lines
+	}
+	if p.sources == nil {
+		p.sources = map[string][]string{}
+	}
+	var lines []string
+	if data, err := os.ReadFile(file); err == nil {
+		lines = strings.Split(string(data), "\n")
+	}
+	p.sources[file] = lines
+	return lines
+}
+
+// traceGroup is a group of the traces with the same signature.
+type traceGroup struct {
+	signature string
+	count     int
+	trace     trace // the first trace of the group
+}
+
+// groupTraces groups traces by their signatures, in the order of their first
+// appearance.
+func groupTraces(traces []trace) []traceGroup {
+	var groups []traceGroup
+	index := map[string]int{}
+	for _, t := range traces {
+		sig := t.signature()
+		if i, ok := index[sig]; ok {
+			groups[i].count++
+			continue
+		}
+		index[sig] = len(groups)
+		groups = append(groups, traceGroup{signature: sig, count: 1, trace: t})
+	}
+	return groups
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"sort"
+)
+
+func runTop(cmd *command, flags *flag.FlagSet, args []string) error {
+	n := flags.Int("n", 10, "print the top `n` entries; 0 means all")
+	by := flags.String("by", "site", "count by `key`: site (the first frame) or signature (all frames)")
+	if err := cmd.parse(flags, args); err != nil {
+		return err
+	}
+	var keyOf func(trace) string
+	switch *by {
+	case "site":
+		keyOf = trace.topSite
+	case "signature":
+		keyOf = trace.signature
+	default:
+		fmt.Fprintf(cmd.stderr, "invalid value %q for flag -by\n", *by)
+		flags.Usage()
+		return errUsage
+	}
+	traces, err := cmd.readTraces(flags.Args())
+	if err != nil {
+		return err
+	}
+
+	type item struct {
+		key    string
+		count  int
+		detail string // of the first trace
+	}
+	var items []*item
+	index := map[string]*item{}
+	for _, t := range traces {
+		key := keyOf(t)
+		if key == "" {
+			continue
+		}
+		if it, ok := index[key]; ok {
+			it.count++
+			continue
+		}
+		it := &item{key: key, count: 1, detail: t.Detail}
+		index[key] = it
+		items = append(items, it)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].count > items[j].count
+	})
+	if 0 < *n && *n < len(items) {
+		items = items[:*n]
+	}
+
+	w := bufio.NewWriter(cmd.stdout)
+	for _, it := range items {
+		fmt.Fprintf(w, "%7d  %s\n         %s\n", it.count, it.key, it.detail)
+	}
+	return w.Flush()
+}
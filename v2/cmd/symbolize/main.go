@@ -0,0 +1,101 @@
+// Command symbolize resolves the stack traces of RawDebugInfo values offline.
+//
+// Usage:
+//
+//	symbolize [-text] binary [file ...]
+//
+// It reads the JSON encoded [stacktrace.RawDebugInfo] values from the files,
+// or from the standard input if no file is given, resolves their program
+// counters using the executable file binary, and writes the resulting
+// [stacktrace.DebugInfo] values to the standard output as JSON lines.
+//
+// With -text, it writes the results in the form of [stacktrace.DebugInfo.Format]
+// separated by blank lines instead.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/goaux/stacktrace/v2"
+	"github.com/goaux/stacktrace/v2/symbolize"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("symbolize", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	text := flags.Bool("text", false, "write the results as text instead of JSON lines")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: symbolize [-text] binary [file ...]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return 2
+	}
+	b, err := symbolize.Open(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(stderr, "symbolize:", err)
+		return 1
+	}
+	defer b.Close()
+
+	write := func(info stacktrace.DebugInfo) error {
+		if *text {
+			_, err := fmt.Fprintf(stdout, "%s\n\n", info.Format())
+			return err
+		}
+		return json.NewEncoder(stdout).Encode(info)
+	}
+	inputs := flags.Args()[1:]
+	if len(inputs) == 0 {
+		inputs = []string{"-"}
+	}
+	status := 0
+	for _, name := range inputs {
+		if err := symbolizeFile(b, name, stdin, write); err != nil {
+			fmt.Fprintln(stderr, "symbolize:", err)
+			status = 1
+		}
+	}
+	return status
+}
+
+func symbolizeFile(b *symbolize.Binary, name string, stdin io.Reader, write func(stacktrace.DebugInfo) error) error {
+	r := stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	dec := json.NewDecoder(r)
+	for {
+		var raw stacktrace.RawDebugInfo
+		if err := dec.Decode(&raw); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		info, err := b.DebugInfo(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if err := write(info); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+//go:noinline
+func traced() error {
+	return stacktrace.New("traced")
+}
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("ELF executables are supported only")
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Skip(err)
+	}
+	traced := traced()
+	raw, err := json.Marshal(stacktrace.GetRawDebugInfo(traced))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := stacktrace.GetDebugInfo(traced)
+
+	t.Run("json", func(t *testing.T) {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		stdin := strings.NewReader(string(raw) + "\n" + string(raw))
+		if status := run([]string{exe}, stdin, stdout, stderr); status != 0 {
+			t.Fatalf("status=%d stderr=%s", status, stderr)
+		}
+		dec := json.NewDecoder(stdout)
+		for i := 0; i < 2; i++ {
+			var got stacktrace.DebugInfo
+			if err := dec.Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Detail != want.Detail || len(got.StackEntries) != len(want.StackEntries) || got.StackEntries[0] != want.StackEntries[0] {
+				t.Errorf("got=%q want=%q", got, want)
+			}
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		if status := run([]string{"-text", exe}, bytes.NewReader(raw), stdout, stderr); status != 0 {
+			t.Fatalf("status=%d stderr=%s", status, stderr)
+		}
+		if got := stdout.String(); !strings.HasPrefix(got, want.Detail+"\n\t"+want.StackEntries[0]+"\n") {
+			t.Errorf("got=%q", got)
+		}
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		if status := run(nil, nil, new(bytes.Buffer), new(bytes.Buffer)); status != 2 {
+			t.Errorf("status=%d must be 2", status)
+		}
+	})
+
+	t.Run("bad input", func(t *testing.T) {
+		stderr := new(bytes.Buffer)
+		if status := run([]string{exe}, strings.NewReader("{"), new(bytes.Buffer), stderr); status != 1 {
+			t.Errorf("status=%d must be 1", status)
+		}
+	})
+}
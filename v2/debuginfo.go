@@ -2,6 +2,7 @@ package stacktrace
 
 import (
 	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -21,33 +22,234 @@ type DebugInfo struct {
 // this information along with the detailed error message in a [DebugInfo] struct.
 //
 // It returns a zero value if err is nil.
+//
+// This is equivalent to:
+//
+//	stacktrace.FormatOptions{}.GetDebugInfo(err)
+//
+// See [FormatOptions.GetDebugInfo].
 func GetDebugInfo(err error) DebugInfo {
+	return FormatOptions{}.GetDebugInfo(err)
+}
+
+// GetDebugInfo extracts debug information from an error in the same way as
+// the [GetDebugInfo] function does, but formats the stack trace frames
+// according to o.
+//
+// It returns a zero value if err is nil.
+func (o FormatOptions) GetDebugInfo(err error) DebugInfo {
 	if err == nil {
 		return DebugInfo{}
 	}
 	return DebugInfo{
 		Detail:       err.Error(),
-		StackEntries: stackEntries(err),
+		StackEntries: o.stackEntries(err),
 	}
 }
 
-func stackEntries(err error) []string {
-	list := ListStackTracers(err)
+func (o FormatOptions) stackEntries(err error) []string {
+	b := &entriesBuilder{o: o}
+	sections := stackSections(err)
+	if o.Tree && len(sections) != 0 {
+		b.appendTree(err, nil)
+		sections = nil
+	}
+	for _, s := range append(sections, snapshotSections(err)...) {
+		if s.heading != "" {
+			b.entries = append(b.entries, s.heading)
+		}
+		if s.tracer != nil {
+			b.appendFrames(s.tracer)
+		}
+	}
+	return b.entries
+}
+
+// stackSection is a StackTracer in an error chain, with the heading entry
+// that precedes its frames in the stack entries.
+type stackSection struct {
+	heading string
+	tracer  StackTracer // nil if the section consists of the heading only
+}
+
+// stackSections returns the StackTracers in err's chain in the order they
+// appear in the stack entries.
+//
+// The heading is "## " followed by the message of the StackTracer, except for
+// the first one whose message is the same as err's, which has no heading.
+func stackSections(err error) []stackSection {
+	list := listStackTracers(err)
 	if len(list) == 0 {
 		return nil
 	}
 	detail := err.Error()
-	var entries []string
-	for i, v := range list {
-		if i != 0 || detail != v.Error() {
-			entries = append(entries, "## "+v.Error())
+	sections := make([]stackSection, len(list))
+	for i, v := range sortCreatedBy(list) {
+		sections[i].tracer = v
+		if isCreatedBy(v) {
+			sections[i].heading = "## created by"
+		} else if i != 0 || detail != v.Error() {
+			sections[i].heading = "## " + v.Error()
 		}
-		callers := v.StackTrace()
-		walkCallersFrames(callers, func(frame *runtime.Frame) {
-			entries = append(entries, frameString(frame))
+	}
+	return sections
+}
+
+// isCreatedBy reports whether v holds the stack of the creator of a goroutine.
+func isCreatedBy(v StackTracer) bool {
+	switch v.(type) {
+	case *createdByStack, *goroutineCreator:
+		return true
+	}
+	return false
+}
+
+// isOtherGoroutine reports whether v holds the stack of a goroutine other
+// than the one in which the error occurred.
+func isOtherGoroutine(v StackTracer) bool {
+	switch v.(type) {
+	case *Goroutine, *goroutineGroup:
+		return true
+	}
+	return isCreatedBy(v)
+}
+
+// entriesBuilder builds the stack entries according to the options.
+type entriesBuilder struct {
+	o       FormatOptions
+	entries []string
+
+	// sources caches the lines of the source files read by o.Source.
+	sources map[string][]string
+
+	// prev holds the frames of the previous StackTracer of the goroutine in
+	// which the error occurred.
+	prev []runtime.Frame
+}
+
+// appendFrames appends the entries for the frames of v.
+//
+// The frames in common with the previous StackTracer of the same goroutine
+// are replaced with a single "... N frames in common with previous" entry,
+// unless o.KeepCommonFrames is set.
+func (b *entriesBuilder) appendFrames(v StackTracer) {
+	var frames []runtime.Frame
+	walkStackTracerFrames(v, func(frame *runtime.Frame) {
+		frames = append(frames, *frame)
+	})
+	common := 0
+	if !b.o.KeepCommonFrames && !isOtherGoroutine(v) {
+		prev := b.prev
+		common = commonTail(len(frames), len(prev), func(i, j int) bool {
+			return sameLocation(&frames[i], &prev[j])
 		})
+		b.prev = frames
+	}
+	elided := 0
+	top := true
+	for i := range frames[:len(frames)-common] {
+		frame := &frames[i]
+		if !b.o.included(frame) {
+			elided++
+			continue
+		}
+		b.appendElided(elided)
+		elided = 0
+		b.entries = append(b.entries, b.o.frameString(frame))
+		b.appendSnippet(frame, top)
+		top = false
+	}
+	b.appendElided(elided)
+
+	// The common frames excluded by Filter are not counted, as if they were
+	// elided.
+	n := 0
+	for i := len(frames) - common; i < len(frames); i++ {
+		if b.o.included(&frames[i]) {
+			n++
+		}
+	}
+	b.entries = appendCommon(b.entries, n)
+	if truncatedStack(v) {
+		b.entries = append(b.entries, truncatedEntry)
+	}
+}
+
+// truncatedEntry is the entry that follows the frames of the stack truncated
+// by the SamplingPolicy.
+const truncatedEntry = "... stack truncated by sampling"
+
+func (o FormatOptions) included(frame *runtime.Frame) bool {
+	return o.Filter == nil || o.Filter(NewFrame(frame))
+}
+
+// commonTail returns the number of the frames at the end of a stack of n
+// frames that are the same as the ones at the end of the previous stack of m
+// frames, like "... N more" of Java. same reports whether the i-th frame of
+// the stack and the j-th frame of the previous one are the same.
+//
+// The first frame is never counted, so that the location is kept.
+func commonTail(n, m int, same func(i, j int) bool) int {
+	k := 0
+	for i, j := n-1, m-1; i > 0 && j >= 0 && same(i, j); i, j = i-1, j-1 {
+		k++
+	}
+	return k
+}
+
+func sameLocation(a, b *runtime.Frame) bool {
+	return a.Function == b.Function && a.File == b.File && a.Line == b.Line
+}
+
+func appendCommon(entries []string, n int) []string {
+	switch {
+	case n == 0:
+		return entries
+	case n == 1:
+		return append(entries, "... 1 frame in common with previous")
+	default:
+		return append(entries, "... "+strconv.Itoa(n)+" frames in common with previous")
+	}
+}
+
+func (o FormatOptions) frameString(frame *runtime.Frame) string {
+	if !o.TrimPaths {
+		return frameString(frame)
+	}
+	trimmed := *frame
+	pkg, _, _ := splitFunction(frame.Function)
+	trimmed.File = trimFile(frame.File, pkg)
+	return frameString(&trimmed)
+}
+
+func (b *entriesBuilder) appendElided(n int) {
+	switch {
+	case !b.o.Elide || n == 0:
+	case n == 1:
+		b.entries = append(b.entries, "... 1 frame elided")
+	default:
+		b.entries = append(b.entries, "... "+strconv.Itoa(n)+" frames elided")
+	}
+}
+
+// sortCreatedBy moves the StackTracers added by Go and Group to the end of
+// list in reverse order, so that the stack of the goroutine in which the error
+// occurred comes first, followed by the stacks of the goroutines that created
+// it, from the nearest to the farthest.
+func sortCreatedBy(list []StackTracer) []StackTracer {
+	sorted := make([]StackTracer, 0, len(list))
+	var createdBy []StackTracer
+	for _, v := range list {
+		if _, ok := v.(*createdByStack); ok {
+			createdBy = append(createdBy, v)
+		} else {
+			sorted = append(sorted, v)
+		}
+	}
+	for i := len(createdBy) - 1; i >= 0; i-- {
+		sorted = append(sorted, createdBy[i])
 	}
-	return entries
+	return sorted
 }
 
 func walkCallersFrames(pc []uintptr, fn func(*runtime.Frame)) {
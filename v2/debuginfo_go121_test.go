@@ -21,9 +21,9 @@ func TestDebugInfo_slog(t *testing.T) {
 		log := slog.New(slog.NewTextHandler(buf, nil))
 		log.Error("error", slog.Any("err", info))
 		txt := buf.String()
-		i := strings.Index(txt, ` err="{`)
+		i := strings.Index(txt, ` err.msg=`)
 		got := txt[i:]
-		want := ` err="{Detail:debuginfo-detail StackEntries:[entry#1 entry#2]}"` + "\n"
+		want := ` err.msg=debuginfo-detail err.frames.0=entry#1 err.frames.1=entry#2` + "\n"
 		if got != want {
 			t.Errorf("got=%q want=%q", got, want)
 		}
@@ -35,7 +35,7 @@ func TestDebugInfo_slog(t *testing.T) {
 		txt := buf.String()
 		i := strings.Index(txt, `"err":{`)
 		got := txt[i:]
-		want := `"err":{"detail":"debuginfo-detail","stack_entries":["entry#1","entry#2"]}}` + "\n"
+		want := `"err":{"msg":"debuginfo-detail","frames":{"0":"entry#1","1":"entry#2"}}}` + "\n"
 		if got != want {
 			t.Errorf("got=%q want=%q", got, want)
 		}
@@ -113,3 +113,34 @@ func ExampleDebugInfo_multiple() {
 		fmt.Println(info.Format()) // this prints 3 stack frames.
 	}
 }
+
+//go:noinline
+func commonFramesInner(depth int) error {
+	if depth == 0 {
+		return stacktrace.New("inner")
+	}
+	return commonFramesInner(depth - 1)
+}
+
+func TestGetDebugInfo_commonFrames(t *testing.T) {
+	inner := commonFramesInner(3)
+	err := stacktrace.NewError(fmt.Errorf("outer: %w", inner), stacktrace.Callers(0))
+
+	all := stacktrace.FormatOptions{KeepCommonFrames: true}.GetDebugInfo(err).StackEntries
+	outerFrames := stacktrace.FramesOf(err.Callers)
+	innerFrames := stacktrace.Frames(inner)
+	if got, want := len(all), len(outerFrames)+1+len(innerFrames); got != want {
+		t.Fatalf("len(all) = %d, must be %d: %q", got, want, all)
+	}
+
+	// The inner trace shares the callers of TestGetDebugInfo_commonFrames,
+	// but not the frame of TestGetDebugInfo_commonFrames itself, whose line
+	// differs.
+	common := len(outerFrames) - 1
+	want := append([]string{}, all[:len(all)-common]...)
+	want = append(want, fmt.Sprintf("... %d frames in common with previous", common))
+	got := stacktrace.GetDebugInfo(err).StackEntries
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+}
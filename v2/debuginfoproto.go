@@ -0,0 +1,288 @@
+package stacktrace
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// DebugInfoTypeURL is the type URL of google.rpc.DebugInfo, which
+// [DebugInfo.MarshalProtoAny] writes in the google.protobuf.Any message.
+const DebugInfoTypeURL = "type.googleapis.com/google.rpc.DebugInfo"
+
+// ErrInvalidProto is returned by [DebugInfo.UnmarshalProto] and
+// [DebugInfo.UnmarshalProtoAny] if the input is not a valid protobuf message
+// of the expected type.
+var ErrInvalidProto = errors.New("stacktrace: invalid protobuf message")
+
+// ErrUnexpectedTypeURL is returned by [DebugInfo.UnmarshalProtoAny] if the
+// google.protobuf.Any message doesn't hold a google.rpc.DebugInfo.
+var ErrUnexpectedTypeURL = errors.New("stacktrace: unexpected type URL")
+
+// The field numbers of google.rpc.DebugInfo and google.protobuf.Any.
+const (
+	debugInfoStackEntries = 1
+	debugInfoDetail       = 2
+	anyTypeURL            = 1
+	anyValue              = 2
+)
+
+// The wire types of the protobuf encoding.
+const (
+	wireVarint     = 0
+	wireFixed64    = 1
+	wireBytes      = 2
+	wireStartGroup = 3
+	wireEndGroup   = 4
+	wireFixed32    = 5
+)
+
+// MarshalProto returns the protobuf wire encoding of info as a
+// google.rpc.DebugInfo message, the same bytes as the ones produced by
+// proto.Marshal for the equivalent errdetails.DebugInfo.
+//
+// Since the protobuf strings must be valid UTF-8, the invalid UTF-8 sequences
+// in info are replaced with U+FFFD.
+func (info DebugInfo) MarshalProto() []byte {
+	return info.appendProto(nil)
+}
+
+// MarshalProtoAny returns the protobuf wire encoding of a google.protobuf.Any
+// message holding info as a google.rpc.DebugInfo, which can be attached to
+// the details of a gRPC status.
+func (info DebugInfo) MarshalProtoAny() []byte {
+	value := info.appendProto(nil)
+	b := appendProtoString(nil, anyTypeURL, DebugInfoTypeURL)
+	if len(value) == 0 {
+		// proto3 omits the empty bytes field.
+		return b
+	}
+	return appendProtoBytes(b, anyValue, value)
+}
+
+func (info DebugInfo) appendProto(b []byte) []byte {
+	for _, entry := range info.StackEntries {
+		b = appendProtoString(b, debugInfoStackEntries, entry)
+	}
+	if info.Detail != "" {
+		b = appendProtoString(b, debugInfoDetail, info.Detail)
+	}
+	return b
+}
+
+// UnmarshalProto parses the protobuf wire encoding of a google.rpc.DebugInfo
+// message into info, replacing its contents.
+//
+// The unknown fields are ignored. It returns [ErrInvalidProto] if b is
+// malformed or if a string is not valid UTF-8.
+func (info *DebugInfo) UnmarshalProto(b []byte) error {
+	var v DebugInfo
+	err := rangeProtoFields(b, func(num, typ uint64, value []byte) error {
+		if typ != wireBytes || (num != debugInfoStackEntries && num != debugInfoDetail) {
+			return nil
+		}
+		if !utf8.Valid(value) {
+			return ErrInvalidProto
+		}
+		if num == debugInfoStackEntries {
+			v.StackEntries = append(v.StackEntries, string(value))
+		} else {
+			v.Detail = string(value)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	*info = v
+	return nil
+}
+
+// UnmarshalProtoAny parses the protobuf wire encoding of a
+// google.protobuf.Any message holding a google.rpc.DebugInfo into info,
+// replacing its contents.
+//
+// It returns [ErrUnexpectedTypeURL] if the message holds another type, and
+// [ErrInvalidProto] if b is malformed.
+func (info *DebugInfo) UnmarshalProtoAny(b []byte) error {
+	var typeURL string
+	var value []byte
+	err := rangeProtoFields(b, func(num, typ uint64, v []byte) error {
+		if typ != wireBytes {
+			return nil
+		}
+		switch num {
+		case anyTypeURL:
+			if !utf8.Valid(v) {
+				return ErrInvalidProto
+			}
+			typeURL = string(v)
+		case anyValue:
+			value = v
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	// Only the part after the last slash names the type, as in anypb.
+	const name = "google.rpc.DebugInfo"
+	if typeURL != name && !strings.HasSuffix(typeURL, "/"+name) {
+		return ErrUnexpectedTypeURL
+	}
+	return info.UnmarshalProto(value)
+}
+
+func appendProtoString(b []byte, num uint64, s string) []byte {
+	if !utf8.ValidString(s) {
+		s = strings.ToValidUTF8(s, "\uFFFD")
+	}
+	b = appendVarint(b, num<<3|wireBytes)
+	b = appendVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendProtoBytes(b []byte, num uint64, v []byte) []byte {
+	b = appendVarint(b, num<<3|wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+// rangeProtoFields calls f for each field of the message b, with the field
+// number, the wire type, and the payload if the wire type is wireBytes.
+// The groups are skipped.
+func rangeProtoFields(b []byte, f func(num, typ uint64, value []byte) error) error {
+	for len(b) != 0 {
+		num, typ, value, rest, err := consumeProtoField(b)
+		if err != nil {
+			return err
+		}
+		if typ == wireEndGroup {
+			return ErrInvalidProto
+		}
+		if err := f(num, typ, value); err != nil {
+			return err
+		}
+		b = rest
+	}
+	return nil
+}
+
+// maxProtoGroupDepth is the maximum nesting depth of the groups, the same as
+// the default recursion limit of protobuf-go.
+const maxProtoGroupDepth = 10000
+
+// consumeProtoField parses the field at the head of b, and returns its number,
+// its wire type, its payload if the wire type is wireBytes, and the rest of b.
+// A group is consumed as a whole, including its end.
+func consumeProtoField(b []byte) (num, typ uint64, value, rest []byte, err error) {
+	num, typ, b, err = consumeProtoTag(b)
+	if err != nil {
+		return 0, 0, nil, nil, err
+	}
+	if typ != wireStartGroup {
+		value, b, err = consumeProtoValue(b, typ)
+		if err != nil {
+			return 0, 0, nil, nil, err
+		}
+		return num, typ, value, b, nil
+	}
+	// The groups are skipped without recursion, since the input may come
+	// from untrusted peers.
+	groups := []uint64{num}
+	for len(groups) != 0 {
+		n, t, next, err := consumeProtoTag(b)
+		if err != nil {
+			return 0, 0, nil, nil, err
+		}
+		switch t {
+		case wireStartGroup:
+			if len(groups) >= maxProtoGroupDepth {
+				return 0, 0, nil, nil, ErrInvalidProto
+			}
+			groups = append(groups, n)
+		case wireEndGroup:
+			if groups[len(groups)-1] != n {
+				return 0, 0, nil, nil, ErrInvalidProto
+			}
+			groups = groups[:len(groups)-1]
+		default:
+			if _, next, err = consumeProtoValue(next, t); err != nil {
+				return 0, 0, nil, nil, err
+			}
+		}
+		b = next
+	}
+	return num, typ, nil, b, nil
+}
+
+// consumeProtoTag parses the tag at the head of b, and returns the field
+// number, the wire type and the rest of b.
+func consumeProtoTag(b []byte) (num, typ uint64, rest []byte, err error) {
+	tag, b, err := consumeVarint(b)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	num, typ = tag>>3, tag&7
+	if num == 0 || num >= 1<<29 {
+		return 0, 0, nil, ErrInvalidProto
+	}
+	return num, typ, b, nil
+}
+
+// consumeProtoValue parses the value of the wire type typ at the head of b,
+// other than a group, and returns the payload if typ is wireBytes, and the
+// rest of b.
+func consumeProtoValue(b []byte, typ uint64) (value, rest []byte, err error) {
+	switch typ {
+	case wireVarint:
+		_, b, err = consumeVarint(b)
+	case wireFixed64:
+		b, err = consumeFixed(b, 8)
+	case wireBytes:
+		var n uint64
+		n, b, err = consumeVarint(b)
+		if err == nil && n > uint64(len(b)) {
+			err = ErrInvalidProto
+		}
+		if err == nil {
+			value, b = b[:n], b[n:]
+		}
+	case wireEndGroup:
+	case wireFixed32:
+		b, err = consumeFixed(b, 4)
+	default:
+		err = ErrInvalidProto
+	}
+	return value, b, err
+}
+
+func consumeVarint(b []byte) (uint64, []byte, error) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		c := b[i]
+		v |= uint64(c&0x7f) << (7 * i)
+		if c < 0x80 {
+			if i == 9 && c > 1 {
+				return 0, nil, ErrInvalidProto // overflows 64 bits
+			}
+			return v, b[i+1:], nil
+		}
+	}
+	return 0, nil, ErrInvalidProto
+}
+
+func consumeFixed(b []byte, n int) ([]byte, error) {
+	if len(b) < n {
+		return nil, ErrInvalidProto
+	}
+	return b[n:], nil
+}
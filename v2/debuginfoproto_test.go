@@ -0,0 +1,224 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestDebugInfo_MarshalProto(t *testing.T) {
+	long := strings.Repeat("x", 300)
+	tests := []struct {
+		name string
+		info stacktrace.DebugInfo
+		want []byte
+	}{
+		{"zero", stacktrace.DebugInfo{}, nil},
+		{
+			"detail only",
+			stacktrace.DebugInfo{Detail: "boom"},
+			[]byte{0x12, 0x04, 'b', 'o', 'o', 'm'},
+		},
+		{
+			"entries before detail",
+			stacktrace.DebugInfo{Detail: "boom", StackEntries: []string{"a", "", "bc"}},
+			[]byte{
+				0x0a, 0x01, 'a',
+				0x0a, 0x00,
+				0x0a, 0x02, 'b', 'c',
+				0x12, 0x04, 'b', 'o', 'o', 'm',
+			},
+		},
+		{
+			"two-byte length",
+			stacktrace.DebugInfo{StackEntries: []string{long}},
+			append([]byte{0x0a, 0xac, 0x02}, long...),
+		},
+		{
+			"invalid UTF-8",
+			stacktrace.DebugInfo{Detail: "a\xffb"},
+			[]byte{0x12, 0x05, 'a', 0xef, 0xbf, 0xbd, 'b'},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.MarshalProto(); !bytes.Equal(got, tt.want) {
+				t.Errorf("got=% x want=% x", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDebugInfo_MarshalProtoAny(t *testing.T) {
+	tests := []struct {
+		name  string
+		info  stacktrace.DebugInfo
+		value []byte // the encoded field 2 of the Any message
+	}{
+		{
+			"entries",
+			stacktrace.DebugInfo{Detail: "boom", StackEntries: []string{"a"}},
+			[]byte{0x12, 0x09, 0x0a, 0x01, 'a', 0x12, 0x04, 'b', 'o', 'o', 'm'},
+		},
+		// The empty value is omitted as proto.Marshal does.
+		{"zero", stacktrace.DebugInfo{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := append([]byte{0x0a, 0x28}, "type.googleapis.com/google.rpc.DebugInfo"...)
+			want = append(want, tt.value...)
+			got := tt.info.MarshalProtoAny()
+			if !bytes.Equal(got, want) {
+				t.Errorf("got=% x want=% x", got, want)
+			}
+
+			var decoded stacktrace.DebugInfo
+			if err := decoded.UnmarshalProtoAny(got); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded, tt.info) {
+				t.Errorf("got=%#v want=%#v", decoded, tt.info)
+			}
+		})
+	}
+}
+
+func TestDebugInfo_UnmarshalProto(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want stacktrace.DebugInfo
+	}{
+		{"empty", nil, stacktrace.DebugInfo{}},
+		{
+			"fields in any order",
+			[]byte{0x12, 0x01, 'x', 0x0a, 0x01, 'a', 0x12, 0x01, 'y', 0x0a, 0x00},
+			stacktrace.DebugInfo{Detail: "y", StackEntries: []string{"a", ""}},
+		},
+		{
+			"unknown fields",
+			[]byte{
+				0x18, 0x96, 0x01, // 3: varint 150
+				0x21, 1, 2, 3, 4, 5, 6, 7, 8, // 4: fixed64
+				0x2a, 0x02, 'z', 'z', // 5: bytes
+				0x35, 1, 2, 3, 4, // 6: fixed32
+				0x3b, 0x08, 0x01, 0x43, 0x44, 0x3c, // 7: group with a varint and an empty group
+				0x0b, 0x0b, 0x0a, 0x01, 'x', 0x0c, 0x0c, // 1: nested groups with a string
+				0x10, 0x01, // 2: varint, the wrong wire type
+				0x0a, 0x01, 'a',
+			},
+			stacktrace.DebugInfo{StackEntries: []string{"a"}},
+		},
+		{
+			"groups nested to the limit",
+			append(bytes.Repeat([]byte{0x0b}, 10000), bytes.Repeat([]byte{0x0c}, 10000)...),
+			stacktrace.DebugInfo{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stacktrace.DebugInfo{Detail: "old", StackEntries: []string{"old"}}
+			if err := got.UnmarshalProto(tt.in); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got=%#v want=%#v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		want := stacktrace.GetDebugInfo(stacktrace.New("boom"))
+		var got stacktrace.DebugInfo
+		if err := got.UnmarshalProto(want.MarshalProto()); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got=%#v want=%#v", got, want)
+		}
+	})
+}
+
+func TestDebugInfo_UnmarshalProto_invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+	}{
+		{"truncated tag", []byte{0x80}},
+		{"truncated length", []byte{0x0a}},
+		{"truncated bytes", []byte{0x0a, 0x02, 'a'}},
+		{"truncated fixed64", []byte{0x21, 1, 2, 3}},
+		{"truncated fixed32", []byte{0x35, 1, 2, 3}},
+		{"field number zero", []byte{0x02, 0x00}},
+		{"reserved wire type", []byte{0x0e}},
+		{"end group", []byte{0x0c}},
+		{"unterminated group", []byte{0x0b, 0x08, 0x01}},
+		{"mismatched group", []byte{0x0b, 0x14}},
+		{"varint overflow", []byte{0x08, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x02}},
+		{"invalid UTF-8", []byte{0x0a, 0x01, 0xff}},
+		{"deeply nested groups", bytes.Repeat([]byte{0x0b}, 50<<20)},
+		{"too deeply nested groups", append(bytes.Repeat([]byte{0x0b}, 10001), bytes.Repeat([]byte{0x0c}, 10001)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := stacktrace.DebugInfo{Detail: "old"}
+			if err := info.UnmarshalProto(tt.in); !errors.Is(err, stacktrace.ErrInvalidProto) {
+				t.Errorf("got=%v want=%v", err, stacktrace.ErrInvalidProto)
+			}
+			if info.Detail != "old" {
+				t.Errorf("info must be unchanged on error: %#v", info)
+			}
+		})
+	}
+}
+
+func TestDebugInfo_UnmarshalProtoAny(t *testing.T) {
+	value := []byte{0x12, 0x04, 'b', 'o', 'o', 'm'}
+	tests := []struct {
+		name    string
+		typeURL string
+		err     error
+	}{
+		{"default", "type.googleapis.com/google.rpc.DebugInfo", nil},
+		{"custom host", "example.com/types/google.rpc.DebugInfo", nil},
+		{"bare name", "google.rpc.DebugInfo", nil},
+		{"other type", "type.googleapis.com/google.rpc.ErrorInfo", stacktrace.ErrUnexpectedTypeURL},
+		{"suffix only", "type.googleapis.com/xgoogle.rpc.DebugInfo", stacktrace.ErrUnexpectedTypeURL},
+		{"missing", "", stacktrace.ErrUnexpectedTypeURL},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b []byte
+			if tt.typeURL != "" {
+				b = append([]byte{0x0a, byte(len(tt.typeURL))}, tt.typeURL...)
+			}
+			b = append(append(b, 0x12, byte(len(value))), value...)
+			var info stacktrace.DebugInfo
+			err := info.UnmarshalProtoAny(b)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("got=%v want=%v", err, tt.err)
+			}
+			if err == nil && info.Detail != "boom" {
+				t.Errorf("got=%q want=%q", info.Detail, "boom")
+			}
+		})
+	}
+}
+
+func ExampleDebugInfo_MarshalProtoAny() {
+	info := stacktrace.DebugInfo{Detail: "boom"}
+	b := info.MarshalProtoAny()
+
+	var decoded stacktrace.DebugInfo
+	if err := decoded.UnmarshalProtoAny(b); err != nil {
+		panic(err)
+	}
+	fmt.Println(decoded.Detail)
+	// Output:
+	// boom
+}
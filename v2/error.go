@@ -2,6 +2,7 @@ package stacktrace
 
 import (
 	"fmt"
+	"io"
 	"runtime"
 )
 
@@ -30,19 +31,50 @@ type Error struct {
 
 	// Callers contains the program counters (PCs) of the function call stack
 	// at the time the error was captured. These can be used to retrieve stack traces.
+	//
+	// The elements of Callers must not be modified once the Error has been
+	// used, because the frames resolved from them are cached. Replacing
+	// Callers with another slice, for example in a copy of the Error, is fine.
 	Callers []uintptr
+
+	// Truncated reports whether Callers holds only the location where the
+	// error was captured, because the capture of the stack was sampled out
+	// by the [SamplingPolicy] in effect.
+	Truncated bool
+
+	// symbols caches the frames resolved from Callers.
+	// It is nil if the Error was not created by NewError.
+	symbols *symbols
 }
 
-var _ StackTracer = Error{}
+var (
+	_ StackTracer   = Error{}
+	_ fmt.Formatter = Error{}
+)
 
 // NewError returns a new Error instance with the given error and caller stack
 // information.
+//
+// The callers are symbolized lazily, at most once, when the frames are first
+// needed by [Error.Error], [GetDebugInfo] and the like.
 func NewError(err error, callers []uintptr) *Error {
-	return &Error{Err: err, Callers: callers}
+	// Allocate the Error and its symbols at once.
+	v := &struct {
+		err     Error
+		symbols symbols
+	}{}
+	v.err = Error{Err: err, Callers: callers, symbols: &v.symbols}
+	return &v.err
 }
 
 func newErrorSkip(err error, skip int) error {
-	return NewError(err, Callers(skip+1))
+	callers, shared, truncated := sampledCallers(skip + 1)
+	if shared != nil {
+		return &Error{Err: err, Callers: callers, Truncated: truncated, symbols: shared}
+	}
+	e := NewError(err, callers)
+	e.Truncated = truncated
+	return e
 }
 
 // Error returns a string representation of the custom error, including
@@ -60,7 +92,12 @@ func (err Error) Error() string {
 	if len(err.Callers) == 0 {
 		return s
 	}
-	frame, _ := runtime.CallersFrames(err.Callers[:1]).Next()
+	var frame runtime.Frame
+	if err.symbols != nil {
+		frame = err.symbols.resolve(err.Callers)[0]
+	} else {
+		frame, _ = runtime.CallersFrames(err.Callers[:1]).Next()
+	}
 	return fmt.Sprintf("%s (%s)", s, frameShortString(&frame))
 }
 
@@ -74,3 +111,33 @@ func (err Error) Unwrap() error {
 func (err Error) StackTrace() []uintptr {
 	return err.Callers
 }
+
+func (err Error) resolvedFrames() []runtime.Frame {
+	if err.symbols == nil {
+		return resolveFrames(err.Callers)
+	}
+	return err.symbols.resolve(err.Callers)
+}
+
+// Format implements [fmt.Formatter].
+//
+// The supported verbs are:
+//
+//	%s, %v  the same string as returned by Error
+//	%q      the Error string, double-quoted
+//	%+v     the multi-line stack trace of the whole error chain, as returned by [Format]
+//	%#v     a Go-syntax representation of the Error with all its exported fields
+//
+// Width and precision flags are applied to %s, %v and %q.
+func (err Error) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		io.WriteString(s, Format(err))
+	case verb == 'v' && s.Flag('#'):
+		fmt.Fprintf(s, "stacktrace.Error{Err:%#v, Callers:%#v, Truncated:%#v}", err.Err, err.Callers, err.Truncated)
+	case verb == 's', verb == 'v', verb == 'q':
+		fmt.Fprintf(s, fmt.FormatString(s, verb), err.Error())
+	default:
+		fmt.Fprintf(s, "%%!%c(stacktrace.Error=%s)", verb, err.Error())
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/goaux/stacktrace/v2"
@@ -37,3 +38,133 @@ func TestError(t *testing.T) {
 		}
 	})
 }
+
+func TestError_Format(t *testing.T) {
+	err := stacktrace.New("hello")
+	msg := err.Error()
+
+	t.Run("s", func(t *testing.T) {
+		for _, format := range []string{"%s", "%v"} {
+			if got := fmt.Sprintf(format, err); got != msg {
+				t.Errorf("format=%s got=%q want=%q", format, got, msg)
+			}
+		}
+	})
+
+	t.Run("q", func(t *testing.T) {
+		want := fmt.Sprintf("%q", msg)
+		if got := fmt.Sprintf("%q", err); got != want {
+			t.Errorf("got=%s want=%s", got, want)
+		}
+	})
+
+	t.Run("width", func(t *testing.T) {
+		want := fmt.Sprintf("%-60s|", msg)
+		if got := fmt.Sprintf("%-60s|", err); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+
+	t.Run("+v", func(t *testing.T) {
+		want := stacktrace.Format(err)
+		if got := fmt.Sprintf("%+v", err); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		if !strings.Contains(want, "\n\t") {
+			t.Errorf("%%+v must be multi-line, got=%q", want)
+		}
+	})
+
+	t.Run("#v", func(t *testing.T) {
+		err := stacktrace.NewError(os.ErrInvalid, []uintptr{1, 2})
+		want := fmt.Sprintf("stacktrace.Error{Err:%#v, Callers:[]uintptr{0x1, 0x2}, Truncated:false}", os.ErrInvalid)
+		if got := fmt.Sprintf("%#v", err); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		err.Truncated = true
+		want = fmt.Sprintf("stacktrace.Error{Err:%#v, Callers:[]uintptr{0x1, 0x2}, Truncated:true}", os.ErrInvalid)
+		if got := fmt.Sprintf("%#v", err); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+
+	t.Run("bad verb", func(t *testing.T) {
+		want := "%!d(stacktrace.Error=" + msg + ")"
+		if got := fmt.Sprintf("%d", err); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+}
+
+func TestError_cached(t *testing.T) {
+	callers := stacktrace.Callers(0)
+	cached := stacktrace.NewError(os.ErrInvalid, callers)
+	plain := stacktrace.Error{Err: os.ErrInvalid, Callers: callers}
+	for i := 0; i < 2; i++ {
+		if got, want := cached.Error(), plain.Error(); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		if got, want := stacktrace.Format(cached), stacktrace.Format(plain); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	}
+}
+
+func TestError_copied(t *testing.T) {
+	err := stacktrace.NewError(os.ErrInvalid, stacktrace.Callers(0))
+	_ = err.Error()
+	c := *err
+	c.Callers = stacktrace.Callers(0)
+	want := stacktrace.Error{Err: os.ErrInvalid, Callers: c.Callers}
+	if got, want := c.Error(), want.Error(); got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+	if got, want := stacktrace.Format(c), stacktrace.Format(want); got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+	if got := err.Error(); got == c.Error() {
+		t.Errorf("the original must keep its location: %q", got)
+	}
+}
+
+func BenchmarkError_Error(b *testing.B) {
+	callers := stacktrace.Callers(0)
+	b.Run("cached", func(b *testing.B) {
+		err := stacktrace.NewError(os.ErrInvalid, callers)
+		for i := 0; i < b.N; i++ {
+			_ = err.Error()
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		err := stacktrace.Error{Err: os.ErrInvalid, Callers: callers}
+		for i := 0; i < b.N; i++ {
+			_ = err.Error()
+		}
+	})
+}
+
+func BenchmarkGetDebugInfo(b *testing.B) {
+	callers := stacktrace.Callers(0)
+	b.Run("cached", func(b *testing.B) {
+		err := stacktrace.NewError(os.ErrInvalid, callers)
+		for i := 0; i < b.N; i++ {
+			_ = stacktrace.GetDebugInfo(err)
+		}
+	})
+	b.Run("uncached", func(b *testing.B) {
+		err := stacktrace.Error{Err: os.ErrInvalid, Callers: callers}
+		for i := 0; i < b.N; i++ {
+			_ = stacktrace.GetDebugInfo(err)
+		}
+	})
+}
+
+func BenchmarkTrace_logged(b *testing.B) {
+	// An error that is logged a few times along the return path.
+	for i := 0; i < b.N; i++ {
+		err := stacktrace.Trace(os.ErrInvalid)
+		for j := 0; j < 3; j++ {
+			_ = stacktrace.Format(err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package stacktrace
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// The keys of the exception attributes defined by the OpenTelemetry semantic
+// conventions, which [ExceptionAttributes] returns.
+const (
+	ExceptionTypeKey       = "exception.type"
+	ExceptionMessageKey    = "exception.message"
+	ExceptionStacktraceKey = "exception.stacktrace"
+)
+
+// Attribute is a key-value pair, which can be converted into an attribute of
+// any OpenTelemetry SDK, e.g. attribute.String(a.Key, a.Value).
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// ExceptionAttributes returns the attributes describing err defined by the
+// OpenTelemetry semantic conventions for exceptions:
+//
+//   - "exception.type" is the Go dynamic type of the root cause of err, that is
+//     the innermost error found by unwrapping err, such as "*io/fs.PathError" or
+//     "syscall.Errno". If an error wraps multiple errors, the first one is
+//     followed.
+//   - "exception.message" is the message of err.
+//   - "exception.stacktrace" is the stack trace of every [StackTracer] in err's
+//     chain, as listed by [ListStackTracers], followed by the stacks of the
+//     creators of the goroutines added by [Go] and [Group], in a format like
+//     the one the runtime prints for a panic. It is omitted if err's chain
+//     doesn't contain any stack.
+//
+// It returns nil if err is nil.
+func ExceptionAttributes(err error) []Attribute {
+	if err == nil {
+		return nil
+	}
+	list := []Attribute{
+		{ExceptionTypeKey, exceptionType(rootCause(err))},
+		{ExceptionMessageKey, err.Error()},
+	}
+	if s := exceptionStacktrace(err); s != "" {
+		list = append(list, Attribute{ExceptionStacktraceKey, s})
+	}
+	return list
+}
+
+// rootCause returns the innermost error of err's chain, following the first
+// non-nil error if an error wraps multiple errors.
+func rootCause(err error) error {
+	for {
+		var next error
+		switch v := err.(type) {
+		case interface{ Unwrap() error }:
+			next = v.Unwrap()
+		case interface{ Unwrap() []error }:
+			for _, e := range v.Unwrap() {
+				if e != nil {
+					next = e
+					break
+				}
+			}
+		}
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
+
+// exceptionType returns the name of the dynamic type of err qualified with
+// its package path, e.g. "*io/fs.PathError".
+func exceptionType(err error) string {
+	t := reflect.TypeOf(err)
+	var prefix string
+	for t.Kind() == reflect.Pointer && t.Name() == "" {
+		prefix += "*"
+		t = t.Elem()
+	}
+	if t.Name() == "" || t.PkgPath() == "" {
+		return prefix + t.String()
+	}
+	return prefix + t.PkgPath() + "." + t.Name()
+}
+
+// exceptionStacktrace renders the StackTracers in err's chain like the runtime
+// renders the goroutines for a panic:
+//
+//	message of the StackTracer:
+//	main.run(...)
+//		/path/to/run.go:10
+//	main.main(...)
+//		/path/to/main.go:11
+//
+// The stack of the creator of a goroutine follows the stack of the goroutine,
+// with a "created by" line for its first frame.
+func exceptionStacktrace(err error) string {
+	var b strings.Builder
+	for _, v := range sortCreatedBy(listStackTracers(err)) {
+		createdBy := isCreatedBy(v)
+		if !createdBy {
+			if b.Len() != 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString(strings.ReplaceAll(v.Error(), "\n", " "))
+			b.WriteString(":\n")
+		}
+		walkStackTracerFrames(v, func(frame *runtime.Frame) {
+			if createdBy {
+				b.WriteString("created by " + frame.Function + "\n")
+				createdBy = false
+			} else {
+				b.WriteString(frame.Function + "(...)\n")
+			}
+			b.WriteString("\t" + frame.File + ":" + strconv.Itoa(frame.Line) + "\n")
+		})
+		if truncatedStack(v) {
+			b.WriteString("...additional frames elided...\n")
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,105 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+type valueError struct{}
+
+func (valueError) Error() string { return "value error" }
+
+type pointerError struct{}
+
+func (*pointerError) Error() string { return "pointer error" }
+
+func TestExceptionAttributes(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		if got := stacktrace.ExceptionAttributes(nil); got != nil {
+			t.Errorf("got=%v", got)
+		}
+	})
+
+	t.Run("type", func(t *testing.T) {
+		tests := []struct {
+			err  error
+			want string
+		}{
+			{errors.New("x"), "*errors.errorString"},
+			{stacktrace.New("x"), "*errors.errorString"},
+			{&pointerError{}, "*github.com/goaux/stacktrace/v2_test.pointerError"},
+			{stacktrace.Trace(&os.PathError{Op: "open", Path: "x", Err: syscall.ENOENT}), "syscall.Errno"},
+			{fmt.Errorf("wrapped: %w", valueError{}), "github.com/goaux/stacktrace/v2_test.valueError"},
+			{errors.Join(nil, stacktrace.Trace(valueError{}), os.ErrClosed), "github.com/goaux/stacktrace/v2_test.valueError"},
+		}
+		for _, tt := range tests {
+			got := stacktrace.ExceptionAttributes(tt.err)
+			if got[0] != (stacktrace.Attribute{Key: stacktrace.ExceptionTypeKey, Value: tt.want}) {
+				t.Errorf("got=%v want=%q", got[0], tt.want)
+			}
+			if got[1] != (stacktrace.Attribute{Key: stacktrace.ExceptionMessageKey, Value: tt.err.Error()}) {
+				t.Errorf("got=%v want=%q", got[1], tt.err.Error())
+			}
+		}
+	})
+
+	t.Run("no StackTracer", func(t *testing.T) {
+		if got := stacktrace.ExceptionAttributes(os.ErrClosed); len(got) != 2 {
+			t.Errorf("the stacktrace must be omitted: %v", got)
+		}
+	})
+
+	t.Run("stacktrace", func(t *testing.T) {
+		first := stacktrace.New("first")
+		_, file, line, _ := runtime.Caller(0)
+		second := stacktrace.New("second")
+		err := errors.Join(first, second)
+		got := stacktrace.ExceptionAttributes(err)
+		if len(got) != 3 || got[2].Key != stacktrace.ExceptionStacktraceKey {
+			t.Fatalf("got=%v", got)
+		}
+		blocks := strings.Split(got[2].Value, "\n\n")
+		if len(blocks) != 2 {
+			t.Fatalf("the stacktrace must have two blocks:\n%s", got[2].Value)
+		}
+		function := "github.com/goaux/stacktrace/v2_test.TestExceptionAttributes.func4"
+		for i, want := range []string{
+			first.Error() + ":\n" + function + "(...)\n\t" + fmt.Sprintf("%s:%d\n", file, line-1),
+			second.Error() + ":\n" + function + "(...)\n\t" + fmt.Sprintf("%s:%d\n", file, line+1),
+		} {
+			if !strings.HasPrefix(blocks[i], want) {
+				t.Errorf("blocks[%d] must start with %q:\n%s", i, want, blocks[i])
+			}
+		}
+	})
+
+	t.Run("created by", func(t *testing.T) {
+		_, file, line, _ := runtime.Caller(0)
+		err := <-stacktrace.Go(func() error { return stacktrace.New("x") })
+		got := stacktrace.ExceptionAttributes(err)[2].Value
+		want := "\ncreated by github.com/goaux/stacktrace/v2_test.TestExceptionAttributes.func5\n\t" + fmt.Sprintf("%s:%d\n", file, line+1)
+		if !strings.Contains(got, want) {
+			t.Errorf("the stacktrace must contain %q:\n%s", want, got)
+		}
+		if strings.Contains(got, "\n\n") {
+			t.Errorf("the creator must follow the stack of the goroutine:\n%s", got)
+		}
+	})
+}
+
+func ExampleExceptionAttributes() {
+	err := stacktrace.Trace(os.ErrNotExist)
+	for _, a := range stacktrace.ExceptionAttributes(err)[:2] {
+		fmt.Printf("%s=%q\n", a.Key, a.Value)
+	}
+	// Output:
+	// exception.type="*errors.errorString"
+	// exception.message="file does not exist (exception_test.go:98 ExampleExceptionAttributes)"
+}
@@ -0,0 +1,92 @@
+package stacktrace
+
+import (
+	"path"
+	"strings"
+)
+
+// Filter reports whether frame should be included in the output.
+type Filter func(frame Frame) bool
+
+var (
+	_ Filter = ExcludeRuntime
+	_ Filter = ExcludeStdlib
+	_ Filter = MainModuleOnly
+)
+
+// ExcludeRuntime is a [Filter] that excludes the frames of the runtime
+// package and its subpackages, such as runtime.goexit and runtime.main.
+func ExcludeRuntime(frame Frame) bool {
+	return !isRuntimePackage(frame.Package)
+}
+
+// ExcludeStdlib is a [Filter] that excludes the frames of the standard
+// library packages, such as testing.tRunner and net/http.(*conn).serve.
+//
+// A package is considered to be in the standard library if the first element
+// of its import path contains no dot and it doesn't belong to the main module.
+func ExcludeStdlib(frame Frame) bool {
+	return !isStdlibPackage(frame.Package)
+}
+
+// MainModuleOnly is a [Filter] that excludes the frames outside the main
+// module, as reported by [runtime/debug.ReadBuildInfo].
+//
+// If the main module is not known, it includes all frames.
+func MainModuleOnly(frame Frame) bool {
+	return mainModulePath() == "" || inMainModule(frame.Package)
+}
+
+// ExcludePackages returns a [Filter] that excludes the frames of the packages
+// whose import paths match any of patterns.
+//
+// The patterns use the syntax of [path.Match]. In addition, a pattern ending
+// with "/..." matches the package itself and all of its subpackages, like the
+// patterns of the go command.
+func ExcludePackages(patterns ...string) Filter {
+	return func(frame Frame) bool {
+		for _, pattern := range patterns {
+			if matchPackage(pattern, frame.Package) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AllOf returns a [Filter] that includes a frame only if all of filters
+// include it.
+func AllOf(filters ...Filter) Filter {
+	return func(frame Frame) bool {
+		for _, filter := range filters {
+			if !filter(frame) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func isRuntimePackage(pkg string) bool {
+	return pkg == "runtime" ||
+		strings.HasPrefix(pkg, "runtime/") ||
+		strings.HasPrefix(pkg, "internal/runtime/")
+}
+
+func isStdlibPackage(pkg string) bool {
+	if pkg == "" || inMainModule(pkg) {
+		return false
+	}
+	first, _, _ := strings.Cut(pkg, "/")
+	return !strings.Contains(first, ".")
+}
+
+func matchPackage(pattern, pkg string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/..."); ok {
+		if pkg == prefix || strings.HasPrefix(pkg, prefix+"/") {
+			return true
+		}
+	}
+	ok, _ := path.Match(pattern, pkg)
+	return ok
+}
@@ -0,0 +1,62 @@
+package stacktrace_test
+
+import (
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestFilter(t *testing.T) {
+	frames := []stacktrace.Frame{
+		{Package: "main"},
+		{Package: "github.com/goaux/stacktrace/v2"},
+		{Package: "github.com/goaux/stacktrace/v2_test"},
+		{Package: "github.com/goaux/stacktrace/v2/slogtrace"},
+		{Package: "github.com/foo/bar"},
+		{Package: "net/http"},
+		{Package: "testing"},
+		{Package: "runtime"},
+		{Package: "runtime/debug"},
+		{Package: "internal/runtime/atomic"},
+	}
+	tests := []struct {
+		name   string
+		filter stacktrace.Filter
+		want   []bool
+	}{
+		{
+			name:   "ExcludeRuntime",
+			filter: stacktrace.ExcludeRuntime,
+			want:   []bool{true, true, true, true, true, true, true, false, false, false},
+		},
+		{
+			name:   "ExcludeStdlib",
+			filter: stacktrace.ExcludeStdlib,
+			want:   []bool{true, true, true, true, true, false, false, false, false, false},
+		},
+		{
+			name:   "MainModuleOnly",
+			filter: stacktrace.MainModuleOnly,
+			want:   []bool{true, true, true, true, false, false, false, false, false, false},
+		},
+		{
+			name:   "ExcludePackages",
+			filter: stacktrace.ExcludePackages("github.com/*/bar", "runtime/...", "github.com/goaux/stacktrace/v2"),
+			want:   []bool{true, false, true, true, false, true, true, false, false, true},
+		},
+		{
+			name:   "AllOf",
+			filter: stacktrace.AllOf(stacktrace.ExcludeRuntime, stacktrace.ExcludePackages("net/...", "testing")),
+			want:   []bool{true, true, true, true, true, false, false, false, false, false},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, frame := range frames {
+				if got := tt.filter(frame); got != tt.want[i] {
+					t.Errorf("%s: got=%t want=%t", frame.Package, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
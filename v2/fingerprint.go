@@ -0,0 +1,69 @@
+package stacktrace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// FingerprintOptions are options for [FingerprintOptions.Fingerprint].
+// A zero FingerprintOptions consists entirely of default values.
+type FingerprintOptions struct {
+	// MaxFrames limits the number of the frames of each StackTracer that are
+	// considered. If zero or negative, all frames are considered.
+	MaxFrames int
+
+	// MainModuleOnly specifies that only the frames of the functions in the
+	// main module are considered.
+	MainModuleOnly bool
+}
+
+// Fingerprint returns a string that identifies the code locations where err
+// occurred, for grouping identical failures.
+//
+// This is equivalent to:
+//
+//	stacktrace.FingerprintOptions{}.Fingerprint(err)
+//
+// See [FingerprintOptions.Fingerprint].
+func Fingerprint(err error) string {
+	return FingerprintOptions{}.Fingerprint(err)
+}
+
+// Fingerprint returns a string that identifies the code locations where err
+// occurred, for grouping identical failures.
+//
+// The fingerprint is a hash of the sequence of function names, file base
+// names and line numbers of the frames of every [StackTracer] in err's chain,
+// as listed by [ListStackTracers], and of the stacks of the creators of the
+// goroutines added by [Go] and [Group]. Program counters, directories of the files
+// and error messages are ignored, so the fingerprint is stable across builds of
+// the same source and across machines with different GOPATHs.
+//
+// It returns an empty string if err's chain doesn't contain any StackTracer.
+func (o FingerprintOptions) Fingerprint(err error) string {
+	list := listStackTracers(err)
+	if len(list) == 0 {
+		return ""
+	}
+	h := sha256.New()
+	for _, v := range list {
+		n := 0
+		walkStackTracerFrames(v, func(frame *runtime.Frame) {
+			if 0 < o.MaxFrames && o.MaxFrames <= n {
+				return
+			}
+			if o.MainModuleOnly {
+				if pkg, _, _ := splitFunction(frame.Function); !inMainModule(pkg) {
+					return
+				}
+			}
+			n++
+			h.Write([]byte(frame.Function + "\n" + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line) + "\n"))
+		})
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
@@ -0,0 +1,75 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func failAt(id int) error {
+	return stacktrace.Errorf("failed at %d", id)
+}
+
+func failElsewhere(id int) error {
+	return stacktrace.Errorf("failed at %d", id)
+}
+
+func TestFingerprint(t *testing.T) {
+	var fingerprints []string
+	for i := 0; i < 2; i++ {
+		fingerprints = append(fingerprints, stacktrace.Fingerprint(failAt(i)))
+	}
+	if fingerprints[0] != fingerprints[1] {
+		t.Errorf("fingerprints must ignore the messages: %q", fingerprints)
+	}
+	if len(fingerprints[0]) != 16 {
+		t.Errorf("fingerprint must be 16 hex digits: %q", fingerprints[0])
+	}
+
+	if a, b := stacktrace.Fingerprint(failAt(0)), stacktrace.Fingerprint(failElsewhere(0)); a == b {
+		t.Errorf("fingerprints of different locations must be different: %q", a)
+	}
+
+	t.Run("wrapped", func(t *testing.T) {
+		err := failAt(0)
+		if a, b := stacktrace.Fingerprint(err), stacktrace.Fingerprint(fmt.Errorf("wrapped: %w", err)); a != b {
+			t.Errorf("untraced wrappers must not change the fingerprint: %q %q", a, b)
+		}
+		joined := errors.Join(err, failElsewhere(0))
+		if a, b := stacktrace.Fingerprint(err), stacktrace.Fingerprint(joined); a == b {
+			t.Errorf("every StackTracer must be considered: %q", a)
+		}
+	})
+
+	t.Run("MaxFrames", func(t *testing.T) {
+		o := stacktrace.FingerprintOptions{MaxFrames: 1}
+		// Only the frame of the function differs between the callers.
+		call1 := func() error { return failAt(0) }
+		call2 := func() error { return failAt(0) }
+		if a, b := o.Fingerprint(call1()), o.Fingerprint(call2()); a != b {
+			t.Errorf("fingerprints of the top frame must be equal: %q %q", a, b)
+		}
+		if a, b := stacktrace.Fingerprint(call1()), stacktrace.Fingerprint(call2()); a == b {
+			t.Errorf("fingerprints of all frames must be different: %q", a)
+		}
+	})
+
+	t.Run("MainModuleOnly", func(t *testing.T) {
+		o := stacktrace.FingerprintOptions{MainModuleOnly: true}
+		err := failAt(0)
+		if a, b := o.Fingerprint(err), stacktrace.Fingerprint(err); a == b {
+			t.Errorf("the frames outside the main module must be ignored: %q", a)
+		}
+	})
+
+	t.Run("untraced", func(t *testing.T) {
+		if s := stacktrace.Fingerprint(errors.New("untraced")); s != "" {
+			t.Errorf("fingerprint must be empty: %q", s)
+		}
+		if s := stacktrace.Fingerprint(nil); s != "" {
+			t.Errorf("fingerprint must be empty: %q", s)
+		}
+	})
+}
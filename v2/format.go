@@ -1,5 +1,66 @@
 package stacktrace
 
+// FormatOptions are options for [FormatOptions.GetDebugInfo] and
+// [FormatOptions.Format].
+// A zero FormatOptions consists entirely of default values.
+type FormatOptions struct {
+	// Filter reports whether a frame is included in the stack entries.
+	// If nil, all frames are included.
+	//
+	// See [ExcludeRuntime], [ExcludeStdlib], [ExcludePackages],
+	// [MainModuleOnly] and [AllOf].
+	Filter Filter
+
+	// Elide specifies that consecutive frames excluded by Filter are replaced
+	// with a single "... N frames elided" entry. If false, the excluded frames
+	// are omitted silently.
+	Elide bool
+
+	// TrimPaths specifies that the file names are rewritten in the form
+	// returned by [Frame.TrimmedFile], such as "$GOROOT/src/net/http/server.go"
+	// and "github.com/foo/bar@v1.2.3/x.go", so that the stack entries don't
+	// depend on the machine where the program was built.
+	// If false, the file names are included verbatim.
+	TrimPaths bool
+
+	// KeepCommonFrames specifies that all the frames of each StackTracer are
+	// included. If false, the frames at the end of a StackTracer that are the
+	// same as the ones at the end of the previous StackTracer, such as the
+	// callers of the function that wrapped an error already traced, are
+	// replaced with a single "... N frames in common with previous" entry.
+	// The stacks of the other goroutines, such as "## created by", are not
+	// compared.
+	KeepCommonFrames bool
+
+	// Source provides the source code for the snippets that follow the frame
+	// entries. If nil, no snippets are included.
+	//
+	// See [OSSource], [FSSource] and [SourceFunc].
+	Source SourceProvider
+
+	// SourceLines is the number of the lines shown before and after the line
+	// of the frame in each snippet. If zero or negative, 2 is used.
+	SourceLines int
+
+	// SourceFrames selects the frames that are followed by the snippets.
+	SourceFrames SourceFrames
+
+	// Tree specifies that the stack entries render the error graph as a tree,
+	// so that the stack traces of the branches made by [errors.Join] and
+	// fmt.Errorf with multiple %w verbs can be told apart.
+	//
+	// Each error wrapped by the root error is rendered as an entry consisting
+	// of its path and message, such as "[0.1] message", indented by its depth.
+	// The path is the list of the indexes of the wrapped errors from the root,
+	// where an Unwrap() error method counts as index 0. The frames of each
+	// StackTracer follow the entry of the error, indented one more level and
+	// prefixed with "| ".
+	//
+	// If false, the StackTracers are rendered in sequence, each under a
+	// "## message" heading.
+	Tree bool
+}
+
 // Format returns a formatted string representation of the [DebugInfo] from err.
 //
 // If err is nil, it returns an empty string.
@@ -13,3 +74,14 @@ package stacktrace
 func Format(err error) string {
 	return GetDebugInfo(err).Format()
 }
+
+// Format returns a formatted string representation of the [DebugInfo] from
+// err in the same way as the [Format] function does, but formats the stack
+// trace frames according to o.
+//
+// This is equivalent to:
+//
+//	o.GetDebugInfo(err).Format()
+func (o FormatOptions) Format(err error) string {
+	return o.GetDebugInfo(err).Format()
+}
@@ -0,0 +1,162 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestFormatOptions(t *testing.T) {
+	err := stacktrace.Trace(os.ErrInvalid)
+	all := stacktrace.GetDebugInfo(err).StackEntries
+	if len(all) != 3 {
+		t.Fatalf("len(all) = %d, must be 3: %q", len(all), all)
+	}
+
+	tests := []struct {
+		name string
+		opts stacktrace.FormatOptions
+		want []string
+	}{
+		{
+			name: "zero",
+			opts: stacktrace.FormatOptions{},
+			want: all,
+		},
+		{
+			name: "ExcludeRuntime",
+			opts: stacktrace.FormatOptions{Filter: stacktrace.ExcludeRuntime},
+			want: all[:2],
+		},
+		{
+			name: "ExcludeRuntime and Elide",
+			opts: stacktrace.FormatOptions{Filter: stacktrace.ExcludeRuntime, Elide: true},
+			want: []string{all[0], all[1], "... 1 frame elided"},
+		},
+		{
+			name: "ExcludeStdlib and Elide",
+			opts: stacktrace.FormatOptions{Filter: stacktrace.ExcludeStdlib, Elide: true},
+			want: []string{all[0], "... 2 frames elided"},
+		},
+		{
+			name: "Elide without Filter",
+			opts: stacktrace.FormatOptions{Elide: true},
+			want: all,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := tt.opts.GetDebugInfo(err)
+			if info.Detail != err.Error() {
+				t.Errorf("got=%q want=%q", info.Detail, err.Error())
+			}
+			if got := info.StackEntries; strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got=%q want=%q", got, tt.want)
+			}
+			if got, want := tt.opts.Format(err), info.Format(); got != want {
+				t.Errorf("got=%q want=%q", got, want)
+			}
+		})
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		o := stacktrace.FormatOptions{Filter: stacktrace.ExcludeRuntime}
+		if got := o.Format(nil); got != "" {
+			t.Errorf("got=%q", got)
+		}
+	})
+}
+
+func ExampleFormatOptions() {
+	o := stacktrace.FormatOptions{
+		Filter: stacktrace.AllOf(stacktrace.ExcludeRuntime, stacktrace.ExcludePackages("net/http")),
+		Elide:  true,
+	}
+	err := stacktrace.New("something went wrong")
+	_ = o.Format(err)
+}
+
+func TestFormatOptions_TrimPaths(t *testing.T) {
+	err := stacktrace.Trace(os.ErrInvalid)
+	o := stacktrace.FormatOptions{TrimPaths: true}
+	entries := o.GetDebugInfo(err).StackEntries
+	if len(entries) != 3 {
+		t.Fatalf("len(entries) = %d, must be 3: %q", len(entries), entries)
+	}
+	if want := "github.com/goaux/stacktrace/v2/format_test.go:"; !strings.HasPrefix(entries[0], want) {
+		t.Errorf("entries[0] must start with %q: %q", want, entries[0])
+	}
+	if want := "$GOROOT/src/testing/testing.go:"; !strings.HasPrefix(entries[1], want) {
+		t.Errorf("entries[1] must start with %q: %q", want, entries[1])
+	}
+
+	frame := stacktrace.Frames(err)[0]
+	if got, want := frame.TrimmedFile(), "github.com/goaux/stacktrace/v2/format_test.go"; got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+}
+
+func TestFormatOptions_Tree(t *testing.T) {
+	a := stacktrace.New("a")
+	b := fmt.Errorf("b: %w", stacktrace.Errorf("c"))
+	err := fmt.Errorf("root: %w", errors.Join(a, b))
+
+	o := stacktrace.FormatOptions{Tree: true, Filter: stacktrace.MainModuleOnly}
+	frame := func(err error) string {
+		return stacktrace.FormatOptions{Filter: stacktrace.MainModuleOnly}.GetDebugInfo(err).StackEntries[0]
+	}
+	want := []string{
+		"[0] " + a.Error() + "; " + b.Error(),
+		"  [0.0] " + a.Error(),
+		"    | " + frame(a),
+		"    [0.0.0] a",
+		"  [0.1] " + b.Error(),
+		"    [0.1.0] " + errors.Unwrap(b).Error(),
+		"      | " + frame(errors.Unwrap(b)),
+		"      [0.1.0.0] c",
+	}
+	info := o.GetDebugInfo(err)
+	if info.Detail != err.Error() {
+		t.Errorf("got=%q want=%q", info.Detail, err.Error())
+	}
+	if got := info.StackEntries; strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+
+	t.Run("root", func(t *testing.T) {
+		err := stacktrace.New("x")
+		want := []string{
+			"| " + frame(err),
+			"[0] x",
+		}
+		if got := o.GetDebugInfo(err).StackEntries; strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+
+	t.Run("created by", func(t *testing.T) {
+		_, file, line, _ := runtime.Caller(0)
+		err := <-stacktrace.Go(func() error { return stacktrace.New("x") })
+		want := []string{
+			"created by",
+			"| " + fmt.Sprintf("%s:%d TestFormatOptions_Tree.func3", file, line+1),
+			"[0] " + errors.Unwrap(err).Error(),
+			"  | " + frame(errors.Unwrap(err)),
+		}
+		if got := o.GetDebugInfo(err).StackEntries; len(got) < len(want) || strings.Join(got[:len(want)], "\n") != strings.Join(want, "\n") {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+
+	t.Run("no StackTracer", func(t *testing.T) {
+		err := errors.Join(os.ErrInvalid, os.ErrClosed)
+		if got := o.GetDebugInfo(err).StackEntries; len(got) != 0 {
+			t.Errorf("got=%q", got)
+		}
+	})
+}
@@ -1,12 +1,101 @@
 package stacktrace
 
 import (
+	"errors"
 	"fmt"
 	"path/filepath"
 	"runtime"
 	"strings"
 )
 
+// Frame is a structured representation of a single stack frame.
+//
+// Unlike the entries of [DebugInfo], which are preformatted strings, Frame
+// provides each element of the frame separately.
+type Frame struct {
+	// PC is the program counter for the location in this frame.
+	PC uintptr `json:"pc,omitempty"`
+
+	// Function is the fully qualified function name,
+	// e.g. "github.com/goaux/stacktrace/v2.(*Error).Error".
+	Function string `json:"function,omitempty"`
+
+	// Package is the import path of the package of the function,
+	// e.g. "github.com/goaux/stacktrace/v2".
+	Package string `json:"package,omitempty"`
+
+	// Receiver is the receiver type if the function is a method,
+	// e.g. "*Error" or "Error". It is empty for plain functions.
+	Receiver string `json:"receiver,omitempty"`
+
+	// Name is the name of the function or method without the package path and
+	// the receiver, e.g. "Error", "Trace" or "Trace.func1".
+	Name string `json:"name,omitempty"`
+
+	// File is the file name of the location in this frame.
+	File string `json:"file,omitempty"`
+
+	// Line is the line number of the location in this frame.
+	Line int `json:"line,omitempty"`
+
+	// Entry is the entry point program counter of the function.
+	// It may be zero if not known.
+	Entry uintptr `json:"entry,omitempty"`
+
+	// Inlined reports whether the function was inlined into its caller.
+	Inlined bool `json:"inlined,omitempty"`
+}
+
+// NewFrame returns a Frame converted from frame.
+func NewFrame(frame *runtime.Frame) Frame {
+	pkg, recv, name := splitFunction(frame.Function)
+	return Frame{
+		PC:       frame.PC,
+		Function: frame.Function,
+		Package:  pkg,
+		Receiver: recv,
+		Name:     name,
+		File:     frame.File,
+		Line:     frame.Line,
+		Entry:    frame.Entry,
+		Inlined:  frame.Func == nil && frame.Function != "",
+	}
+}
+
+// String returns the frame in the same format as the entries of [DebugInfo]:
+// "<file>:<line> <function>".
+func (frame Frame) String() string {
+	return fmt.Sprintf("%s:%d %s", frame.File, frame.Line, frameFunction(frame.Function))
+}
+
+// Frames returns the frames of the first [StackTracer] in err's chain,
+// that is the one [errors.As] would find.
+//
+// Like [GetDebugInfo], it stops at main.main.
+// It returns nil if err's chain doesn't contain any StackTracer.
+func Frames(err error) []Frame {
+	var v StackTracer
+	if !errors.As(err, &v) {
+		return nil
+	}
+	var list []Frame
+	walkStackTracerFrames(v, func(frame *runtime.Frame) {
+		list = append(list, NewFrame(frame))
+	})
+	return list
+}
+
+// FramesOf returns the frames for the given program counters.
+//
+// Like [GetDebugInfo], it stops at main.main.
+func FramesOf(callers []uintptr) []Frame {
+	var list []Frame
+	walkCallersFrames(callers, func(frame *runtime.Frame) {
+		list = append(list, NewFrame(frame))
+	})
+	return list
+}
+
 func frameString(frame *runtime.Frame) string {
 	return fmt.Sprintf(
 		"%s:%d %s",
@@ -33,3 +122,77 @@ func frameFunction(s string) string {
 	}
 	return s
 }
+
+// splitFunction splits a fully qualified function name, as reported by
+// runtime.Frame.Function, into the package path, the receiver type and the
+// function name.
+//
+//	"github.com/foo/bar.(*T).Method"  => "github.com/foo/bar", "*T", "Method"
+//	"github.com/foo/bar.T.Method"     => "github.com/foo/bar", "T", "Method"
+//	"github.com/foo/bar.Func.func1"   => "github.com/foo/bar", "", "Func.func1"
+//	"main.main"                       => "main", "", "main"
+//	"github.com/foo/bar.glob..func1"  => "github.com/foo/bar", "", "glob..func1"
+func splitFunction(s string) (pkg, recv, name string) {
+	i := strings.LastIndexByte(s, '/') + 1
+	j := strings.IndexByte(s[i:], '.')
+	if j == -1 {
+		return "", "", s
+	}
+	// The linker escapes the dots in the last element of the package path.
+	pkg, name = strings.ReplaceAll(s[:i+j], "%2e", "."), s[i+j+1:]
+	if strings.HasPrefix(name, "(") {
+		if k := strings.Index(name, ")."); k != -1 {
+			return pkg, name[1:k], name[k+2:]
+		}
+		return pkg, "", name
+	}
+	first, rest, ok := cutDot(name)
+	if !ok {
+		return pkg, "", name
+	}
+	// An empty element, as in "glob..func1" of the closures in the package
+	// level variables, is not a method name either.
+	if next, _, _ := cutDot(rest); next == "" || isClosureName(next) {
+		return pkg, "", name
+	}
+	return pkg, first, rest
+}
+
+// cutDot slices s around the first '.' outside of square brackets,
+// which enclose type parameters such as "[...]".
+func cutDot(s string) (before, after string, found bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				return s[:i], s[i+1:], true
+			}
+		}
+	}
+	return s, "", false
+}
+
+// isClosureName reports whether s is a name the compiler gives to function
+// literals and wrappers, such as "func1", "1", "gowrap1" or "deferwrap1".
+func isClosureName(s string) bool {
+	for _, prefix := range []string{"func", "gowrap", "deferwrap", ""} {
+		if t, ok := strings.CutPrefix(s, prefix); ok && t != "" && isDigits(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || '9' < s[i] {
+			return false
+		}
+	}
+	return true
+}
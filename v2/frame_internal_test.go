@@ -0,0 +1,37 @@
+package stacktrace
+
+import "testing"
+
+func TestSplitFunction(t *testing.T) {
+	tests := []struct {
+		function string
+		pkg      string
+		recv     string
+		name     string
+	}{
+		{"main.main", "main", "", "main"},
+		{"runtime.goexit", "runtime", "", "goexit"},
+		{"github.com/foo/bar.Func", "github.com/foo/bar", "", "Func"},
+		{"github.com/foo/bar.(*T).Method", "github.com/foo/bar", "*T", "Method"},
+		{"github.com/foo/bar.T.Method", "github.com/foo/bar", "T", "Method"},
+		{"github.com/foo/bar.T.Method.func1", "github.com/foo/bar", "T", "Method.func1"},
+		{"github.com/foo/bar.Func.func1", "github.com/foo/bar", "", "Func.func1"},
+		{"github.com/foo/bar.Func.func1.2", "github.com/foo/bar", "", "Func.func1.2"},
+		{"github.com/foo/bar.Func.gowrap1", "github.com/foo/bar", "", "Func.gowrap1"},
+		{"github.com/foo/bar.glob..func1", "github.com/foo/bar", "", "glob..func1"},
+		{"github.com/foo/bar.G[...].func1", "github.com/foo/bar", "", "G[...].func1"},
+		{"github.com/foo/bar.S[...].M", "github.com/foo/bar", "S[...]", "M"},
+		{"github.com/foo/bar.(*S[...]).M", "github.com/foo/bar", "*S[...]", "M"},
+		{"gopkg.in/yaml%2ev3.Unmarshal", "gopkg.in/yaml.v3", "", "Unmarshal"},
+		{"net/http.(*conn).serve", "net/http", "*conn", "serve"},
+		{"", "", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.function, func(t *testing.T) {
+			pkg, recv, name := splitFunction(tt.function)
+			if pkg != tt.pkg || recv != tt.recv || name != tt.name {
+				t.Errorf("got=(%q, %q, %q) want=(%q, %q, %q)", pkg, recv, name, tt.pkg, tt.recv, tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+type frameTester struct{}
+
+//go:noinline
+func (*frameTester) capture() error {
+	return stacktrace.New("frame")
+}
+
+func TestFrames(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", new(frameTester).capture())
+	_, file, _, _ := runtime.Caller(0)
+	frames := stacktrace.Frames(err)
+	if len(frames) == 0 {
+		t.Fatal("len(frames) must be greater than 0")
+	}
+	got := frames[0]
+	want := stacktrace.Frame{
+		PC:       got.PC,
+		Function: "github.com/goaux/stacktrace/v2_test.(*frameTester).capture",
+		Package:  "github.com/goaux/stacktrace/v2_test",
+		Receiver: "*frameTester",
+		Name:     "capture",
+		File:     file,
+		Line:     17,
+		Entry:    got.Entry,
+	}
+	if got != want {
+		t.Errorf("got=%+v want=%+v", got, want)
+	}
+	if got.PC == 0 || got.Entry == 0 {
+		t.Errorf("PC and Entry must not be zero: %+v", got)
+	}
+
+	entries := stacktrace.GetDebugInfo(errors.Unwrap(err)).StackEntries
+	if len(entries) != len(frames) {
+		t.Fatalf("len(entries)=%d must be len(frames)=%d", len(entries), len(frames))
+	}
+	for i, frame := range frames {
+		if got, want := frame.String(), entries[i]; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	}
+
+	t.Run("no stacktracer", func(t *testing.T) {
+		if frames := stacktrace.Frames(errors.New("")); frames != nil {
+			t.Errorf("frames must be nil: %v", frames)
+		}
+	})
+}
+
+func TestFramesOf(t *testing.T) {
+	err := stacktrace.Trace(os.ErrInvalid).(*stacktrace.Error)
+	got := stacktrace.FramesOf(err.Callers)
+	want := stacktrace.Frames(err)
+	if len(got) != len(want) {
+		t.Fatalf("len(got)=%d len(want)=%d", len(got), len(want))
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("got[%d]=%+v want[%d]=%+v", i, got[i], i, want[i])
+		}
+	}
+	if frames := stacktrace.FramesOf(nil); frames != nil {
+		t.Errorf("frames must be nil: %v", frames)
+	}
+}
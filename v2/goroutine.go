@@ -0,0 +1,186 @@
+package stacktrace
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// Go calls fn in a new goroutine and returns a channel that receives the
+// result of fn.
+//
+// Go captures the call stack of its caller when it is called. If fn returns a
+// non-nil error, the error is wrapped with the captured stack, which
+// [GetDebugInfo] renders after the other stack traces under a "## created by"
+// heading, similar to the runtime's goroutine dump.
+// The wrapper does not change the message of the error, and it can be
+// unwrapped by [errors.Unwrap]. It is not a [StackTracer], so that
+// [HasStackTracer], [Latest], [Frames] and [errors.As] find the stack of the
+// error in the goroutine, and [Trace] still adds the stack if there is none.
+//
+// The returned channel is buffered, so the goroutine finishes even if the
+// result is never received.
+func Go(fn func() error) <-chan error {
+	callers := Callers(1)
+	ch := make(chan error, 1)
+	go func() {
+		ch <- withCreatedBy(fn(), callers)
+	}()
+	return ch
+}
+
+// Group is a collection of goroutines working on subtasks of a common task.
+//
+// Group provides the same API as golang.org/x/sync/errgroup.Group.
+// In addition, like [Go], the errors returned by the goroutines carry the
+// call stack of [Group.Go] or [Group.TryGo] that started them.
+//
+// A zero Group is valid, has no limit on the number of active goroutines,
+// and does not cancel on error.
+type Group struct {
+	cancel func(error)
+
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	errOnce sync.Once
+	err     error
+}
+
+// WithContext returns a new Group and an associated Context derived from ctx.
+//
+// The derived Context is canceled the first time a function passed to Go
+// returns a non-nil error or the first time Wait returns, whichever occurs
+// first.
+func WithContext(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return &Group{cancel: cancel}, ctx
+}
+
+// Wait blocks until all function calls from the Go method have returned, then
+// returns the first non-nil error (if any) from them.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	if g.cancel != nil {
+		g.cancel(g.err)
+	}
+	return g.err
+}
+
+// Go calls the given function in a new goroutine.
+// It blocks until the new goroutine can be added without the number of active
+// goroutines in the group exceeding the configured limit.
+//
+// The first call to return a non-nil error cancels the group's context, if the
+// group was created by calling WithContext. The error will be returned by Wait.
+func (g *Group) Go(fn func() error) {
+	callers := Callers(1)
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+	g.start(fn, callers)
+}
+
+// TryGo calls the given function in a new goroutine only if the number of
+// active goroutines in the group is currently below the configured limit.
+//
+// The return value reports whether the goroutine was started.
+func (g *Group) TryGo(fn func() error) bool {
+	callers := Callers(1)
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+	g.start(fn, callers)
+	return true
+}
+
+func (g *Group) start(fn func() error, callers []uintptr) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+		if err := withCreatedBy(fn(), callers); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				if g.cancel != nil {
+					g.cancel(g.err)
+				}
+			})
+		}
+	}()
+}
+
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// SetLimit limits the number of active goroutines in this group to at most n.
+// A negative value indicates no limit.
+//
+// Any subsequent call to the Go method will block until it can add an active
+// goroutine without exceeding the configured limit.
+//
+// The limit must not be modified while any goroutines in the group are active.
+func (g *Group) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+	if len(g.sem) != 0 {
+		panic("stacktrace: modify limit while goroutines in the group are still active")
+	}
+	g.sem = make(chan struct{}, n)
+}
+
+// createdByError holds the call stack of the function that started the
+// goroutine in which err occurred.
+//
+// It is not a StackTracer; see the creator interface.
+type createdByError struct {
+	err     error
+	callers []uintptr
+	symbols symbols
+}
+
+func withCreatedBy(err error, callers []uintptr) error {
+	if err == nil {
+		return nil
+	}
+	return &createdByError{err: err, callers: callers}
+}
+
+// Error returns the message of the wrapped error unchanged.
+func (err *createdByError) Error() string {
+	return err.err.Error()
+}
+
+func (err *createdByError) Unwrap() error {
+	return err.err
+}
+
+func (err *createdByError) creatorStack() StackTracer {
+	return (*createdByStack)(err)
+}
+
+// createdByStack is the StackTracer of a createdByError, which is not in the
+// error chain.
+type createdByStack createdByError
+
+// Error returns the message of the error that occurred in the goroutine.
+func (err *createdByStack) Error() string {
+	return err.err.Error()
+}
+
+func (err *createdByStack) StackTrace() []uintptr {
+	return err.callers
+}
+
+func (err *createdByStack) resolvedFrames() []runtime.Frame {
+	return err.symbols.resolve(err.callers)
+}
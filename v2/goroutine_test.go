@@ -0,0 +1,167 @@
+package stacktrace_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestGo(t *testing.T) {
+	t.Run("traced", func(t *testing.T) {
+		err := <-stacktrace.Go(func() error {
+			return stacktrace.Trace(os.ErrInvalid)
+		})
+		if !errors.Is(err, os.ErrInvalid) {
+			t.Errorf("err must be os.ErrInvalid: %v", err)
+		}
+		if got, want := err.Error(), "invalid argument (goroutine_test.go:17 TestGo.func1.1)"; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		entries := stacktrace.GetDebugInfo(err).StackEntries
+		i := indexOf(entries, "## created by")
+		if i <= 0 {
+			t.Fatalf("entries must have the created by heading after the stack of the goroutine:\n%s", strings.Join(entries, "\n"))
+		}
+		if !strings.Contains(entries[0], "goroutine_test.go:17 ") {
+			t.Errorf("entries[0] must be the stack of the goroutine: %q", entries[0])
+		}
+		if !strings.Contains(entries[i+1], "goroutine_test.go:16 TestGo.func1") {
+			t.Errorf("entries[%d] must be the caller of Go: %q", i+1, entries[i+1])
+		}
+	})
+
+	t.Run("untraced", func(t *testing.T) {
+		err := <-stacktrace.Go(func() error { return os.ErrInvalid })
+		if err.Error() != os.ErrInvalid.Error() {
+			t.Errorf("the message must not be changed: %q", err.Error())
+		}
+		entries := stacktrace.GetDebugInfo(err).StackEntries
+		if len(entries) < 2 || entries[0] != "## created by" {
+			t.Errorf("entries must start with the created by heading: %q", entries)
+		}
+	})
+
+	t.Run("nested", func(t *testing.T) {
+		err := <-stacktrace.Go(func() error {
+			return <-stacktrace.Go(func() error {
+				return stacktrace.New("nested")
+			})
+		})
+		entries := stacktrace.GetDebugInfo(err).StackEntries
+		var lines []string
+		for i, entry := range entries {
+			if entry == "## created by" {
+				lines = append(lines, entries[i+1])
+			}
+		}
+		if len(lines) != 2 ||
+			!strings.Contains(lines[0], "goroutine_test.go:51 TestGo.func3.1") ||
+			!strings.Contains(lines[1], "goroutine_test.go:50 TestGo.func3") {
+			t.Errorf("created by must be in order from the nearest:\n%s", strings.Join(entries, "\n"))
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		if err := <-stacktrace.Go(func() error { return nil }); err != nil {
+			t.Errorf("err must be nil: %v", err)
+		}
+	})
+
+	t.Run("not a StackTracer", func(t *testing.T) {
+		err := <-stacktrace.Go(func() error { return stacktrace.New("boom") })
+		if got, want := stacktrace.Latest(err), errors.Unwrap(err); got != want {
+			t.Errorf("Latest must be the error of the goroutine: got=%v want=%v", got, want)
+		}
+		if frames := stacktrace.Frames(err); len(frames) == 0 || frames[0].Line != 76 {
+			t.Errorf("Frames must start where the error occurred: %v", frames)
+		}
+
+		err = <-stacktrace.Go(func() error { return os.ErrInvalid })
+		if stacktrace.HasStackTracer(err) {
+			t.Errorf("err must not have a StackTracer: %v", err)
+		}
+		if got, want := stacktrace.Trace(err).Error(), "invalid argument (goroutine_test.go:88 TestGo.func5)"; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+}
+
+func TestGroup(t *testing.T) {
+	t.Run("Wait", func(t *testing.T) {
+		var g stacktrace.Group
+		g.Go(func() error { return nil })
+		g.Go(func() error { return os.ErrInvalid })
+		err := g.Wait()
+		if !errors.Is(err, os.ErrInvalid) {
+			t.Errorf("err must be os.ErrInvalid: %v", err)
+		}
+		if entries := stacktrace.GetDebugInfo(err).StackEntries; indexOf(entries, "## created by") != 0 {
+			t.Errorf("entries must start with the created by heading: %q", entries)
+		}
+	})
+
+	t.Run("WithContext", func(t *testing.T) {
+		g, ctx := stacktrace.WithContext(context.Background())
+		g.Go(func() error { return os.ErrInvalid })
+		g.Go(func() error {
+			<-ctx.Done()
+			return nil
+		})
+		if err := g.Wait(); !errors.Is(err, os.ErrInvalid) {
+			t.Errorf("err must be os.ErrInvalid: %v", err)
+		}
+		if err := context.Cause(ctx); !errors.Is(err, os.ErrInvalid) {
+			t.Errorf("cause must be os.ErrInvalid: %v", err)
+		}
+	})
+
+	t.Run("SetLimit", func(t *testing.T) {
+		var g stacktrace.Group
+		g.SetLimit(1)
+		block := make(chan struct{})
+		g.Go(func() error {
+			<-block
+			return nil
+		})
+		if g.TryGo(func() error { return nil }) {
+			t.Error("TryGo must fail while the limit is reached")
+		}
+		close(block)
+		var n atomic.Int32
+		for i := 0; i < 3; i++ {
+			g.Go(func() error {
+				n.Add(1)
+				return nil
+			})
+		}
+		if err := g.Wait(); err != nil {
+			t.Errorf("err must be nil: %v", err)
+		}
+		if n.Load() != 3 {
+			t.Errorf("n must be 3, but %d", n.Load())
+		}
+	})
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func ExampleGo() {
+	ch := stacktrace.Go(func() error {
+		return stacktrace.New("failed in the goroutine")
+	})
+	if err := <-ch; err != nil {
+		_ = stacktrace.Format(err) // includes "## created by" and the stack of the caller of Go.
+	}
+}
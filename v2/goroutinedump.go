@@ -0,0 +1,302 @@
+package stacktrace
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoGoroutine is returned by [ParseGoroutineDump] if the input contains no
+// goroutine.
+var ErrNoGoroutine = errors.New("stacktrace: no goroutine in the dump")
+
+// GoroutineDump is a goroutine dump parsed by [ParseGoroutineDump], such as
+// the output of [runtime.Stack] with all set to true, the output of a process
+// that received SIGQUIT, or the output of a process that crashed with a panic.
+//
+// GoroutineDump is an error whose chain contains the goroutines, so that it
+// can be passed to [GetDebugInfo], [Format] and the other functions of this
+// package. Each goroutine is rendered under a "## goroutine N [state]" heading.
+type GoroutineDump struct {
+	// Message is the text preceding the goroutines,
+	// e.g. "panic: something went wrong" or "SIGQUIT: quit".
+	Message string `json:"message,omitempty"`
+
+	// Goroutines are the goroutines in the order they appear in the dump.
+	Goroutines []*Goroutine `json:"goroutines,omitempty"`
+}
+
+// Error returns the message of the dump, or "goroutine dump" if it is empty.
+func (d *GoroutineDump) Error() string {
+	if d.Message == "" {
+		return "goroutine dump"
+	}
+	return d.Message
+}
+
+// Unwrap returns the goroutines.
+func (d *GoroutineDump) Unwrap() []error {
+	list := make([]error, len(d.Goroutines))
+	for i, g := range d.Goroutines {
+		list[i] = g
+	}
+	return list
+}
+
+// Goroutine is a goroutine in a [GoroutineDump].
+//
+// Goroutine is a [StackTracer] whose frames are already resolved.
+// Since the goroutine doesn't belong to the current process, its StackTrace
+// method returns nil; use [Frames], [GetDebugInfo] or the Frames field instead.
+// GetDebugInfo renders the CreatedBy frame after the frames of the goroutine,
+// under a "## created by" heading.
+type Goroutine struct {
+	// ID is the goroutine ID.
+	ID int64 `json:"id"`
+
+	// State is the state of the goroutine, e.g. "running" or "chan receive".
+	State string `json:"state,omitempty"`
+
+	// Wait is the approximate duration for which the goroutine has been
+	// blocked. The runtime reports it in minutes, only if it is at least one
+	// minute.
+	Wait time.Duration `json:"wait,omitempty"`
+
+	// LockedToThread reports whether the goroutine is locked to the thread.
+	LockedToThread bool `json:"locked_to_thread,omitempty"`
+
+	// Frames are the frames of the goroutine, from the innermost.
+	Frames []GoroutineFrame `json:"frames,omitempty"`
+
+	// FramesElided reports whether the runtime omitted some frames of the
+	// goroutine because the stack is too deep.
+	FramesElided bool `json:"frames_elided,omitempty"`
+
+	// CreatedBy is the location of the go statement that created the
+	// goroutine. It is nil for the main goroutine and the goroutines created
+	// by the runtime before main.
+	CreatedBy *GoroutineFrame `json:"created_by,omitempty"`
+
+	// CreatorID is the ID of the goroutine that created the goroutine, or zero
+	// if it is not reported.
+	CreatorID int64 `json:"creator_id,omitempty"`
+}
+
+// GoroutineFrame is a frame of a [Goroutine].
+type GoroutineFrame struct {
+	Frame
+
+	// Args is the argument list as printed by the runtime,
+	// e.g. "0xc000012345, {0x4b1234, 0x5}". It is "..." for inlined frames.
+	Args string `json:"args,omitempty"`
+
+	// Offset is the offset of the program counter from the entry point of the
+	// function, that is the "+0x45" part of the location.
+	Offset uintptr `json:"offset,omitempty"`
+}
+
+// Error returns the header of the goroutine without the trailing colon,
+// e.g. "goroutine 18 [chan receive, 5 minutes, locked to thread]".
+func (g *Goroutine) Error() string {
+	var b strings.Builder
+	b.WriteString("goroutine ")
+	b.WriteString(strconv.FormatInt(g.ID, 10))
+	b.WriteString(" [")
+	b.WriteString(g.State)
+	if g.Wait != 0 {
+		b.WriteString(", ")
+		b.WriteString(strconv.FormatInt(int64(g.Wait/time.Minute), 10))
+		b.WriteString(" minutes")
+	}
+	if g.LockedToThread {
+		b.WriteString(", locked to thread")
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// creatorStack returns the stack holding the CreatedBy frame, which
+// GetDebugInfo renders under a "## created by" heading, or nil if CreatedBy
+// is nil.
+func (g *Goroutine) creatorStack() StackTracer {
+	if g.CreatedBy == nil {
+		return nil
+	}
+	return &goroutineCreator{g: g}
+}
+
+// StackTrace returns nil, since the goroutine doesn't belong to the current
+// process.
+func (g *Goroutine) StackTrace() []uintptr {
+	return nil
+}
+
+func (g *Goroutine) resolvedFrames() []runtime.Frame {
+	list := make([]runtime.Frame, len(g.Frames))
+	for i, frame := range g.Frames {
+		list[i] = frame.runtimeFrame()
+	}
+	return list
+}
+
+func (frame GoroutineFrame) runtimeFrame() runtime.Frame {
+	return runtime.Frame{
+		Function: frame.Function,
+		File:     frame.File,
+		Line:     frame.Line,
+	}
+}
+
+// goroutineCreator is the stack of the creator of g, which holds the
+// CreatedBy frame of g. It is not in the error chain; see the creator
+// interface.
+type goroutineCreator struct {
+	g *Goroutine
+}
+
+func (err *goroutineCreator) Error() string {
+	s := "created by " + err.g.CreatedBy.Function
+	if err.g.CreatorID != 0 {
+		s += " in goroutine " + strconv.FormatInt(err.g.CreatorID, 10)
+	}
+	return s
+}
+
+func (err *goroutineCreator) StackTrace() []uintptr {
+	return nil
+}
+
+func (err *goroutineCreator) resolvedFrames() []runtime.Frame {
+	return []runtime.Frame{err.g.CreatedBy.runtimeFrame()}
+}
+
+var goroutineHeader = regexp.MustCompile(`^goroutine (\d+)(?: gp=\S+ m=\S+(?: mp=\S+)?)? \[(.*)\]:$`)
+
+// ParseGoroutineDump parses a goroutine dump in the text format of the
+// runtime, such as the output of [runtime.Stack] with all set to true, the
+// output of a process that received SIGQUIT, or the output of a process that
+// crashed with a panic.
+//
+// The lines that are not understood are ignored.
+// It returns [ErrNoGoroutine] if r contains no goroutine.
+func ParseGoroutineDump(r io.Reader) (*GoroutineDump, error) {
+	d := &GoroutineDump{}
+	var message []string
+	var g *Goroutine          // the current goroutine, nil between goroutines
+	var frame *GoroutineFrame // the frame waiting for its location
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), " \r")
+		if m := goroutineHeader.FindStringSubmatch(line); m != nil {
+			g, frame = parseGoroutineHeader(m[1], m[2]), nil
+			d.Goroutines = append(d.Goroutines, g)
+			continue
+		}
+		if g == nil {
+			if len(d.Goroutines) == 0 && line != "" {
+				message = append(message, line)
+			}
+			continue
+		}
+		switch {
+		case line == "":
+			g, frame = nil, nil
+		case strings.HasPrefix(line, "\t"):
+			if frame != nil {
+				parseGoroutineLocation(frame, line[1:])
+				frame = nil
+			}
+		case line == "...additional frames elided...":
+			g.FramesElided = true
+			frame = nil
+		case strings.HasPrefix(line, "created by "):
+			function := strings.TrimPrefix(line, "created by ")
+			if before, after, ok := strings.Cut(function, " in goroutine "); ok {
+				function = before
+				g.CreatorID, _ = strconv.ParseInt(after, 10, 64)
+			}
+			g.CreatedBy = newGoroutineFrame(function, "")
+			frame = g.CreatedBy
+		case strings.HasSuffix(line, ")"):
+			i := strings.LastIndexByte(line, '(')
+			if i <= 0 {
+				frame = nil
+				continue
+			}
+			g.Frames = append(g.Frames, *newGoroutineFrame(line[:i], line[i+1:len(line)-1]))
+			frame = &g.Frames[len(g.Frames)-1]
+		default:
+			frame = nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	if len(d.Goroutines) == 0 {
+		return nil, ErrNoGoroutine
+	}
+	d.Message = strings.Join(message, "\n")
+	return d, nil
+}
+
+// parseGoroutineHeader returns a Goroutine for the ID and the bracketed part
+// of the header, e.g. "chan receive, 5 minutes, locked to thread".
+func parseGoroutineHeader(id, status string) *Goroutine {
+	g := &Goroutine{}
+	g.ID, _ = strconv.ParseInt(id, 10, 64)
+	for i, s := range strings.Split(status, ", ") {
+		switch {
+		case i == 0:
+			g.State = s
+		case s == "locked to thread":
+			g.LockedToThread = true
+		case strings.HasSuffix(s, " minutes"):
+			if n, err := strconv.Atoi(strings.TrimSuffix(s, " minutes")); err == nil {
+				g.Wait = time.Duration(n) * time.Minute
+			}
+		}
+	}
+	return g
+}
+
+func newGoroutineFrame(function, args string) *GoroutineFrame {
+	pkg, recv, name := splitFunction(function)
+	return &GoroutineFrame{
+		Frame: Frame{
+			Function: function,
+			Package:  pkg,
+			Receiver: recv,
+			Name:     name,
+			Inlined:  args == "...",
+		},
+		Args: args,
+	}
+}
+
+// parseGoroutineLocation sets the location of frame from s,
+// e.g. "/path/to/file.go:12 +0x45", which may be followed by
+// " fp=0x... sp=0x... pc=0x..." if GOTRACEBACK is system or higher.
+func parseGoroutineLocation(frame *GoroutineFrame, s string) {
+	if i := strings.Index(s, " fp="); i != -1 {
+		s = s[:i]
+	}
+	if i := strings.LastIndex(s, " +0x"); i != -1 {
+		if n, err := strconv.ParseUint(s[i+4:], 16, 64); err == nil {
+			frame.Offset = uintptr(n)
+		}
+		s = s[:i]
+	}
+	if i := strings.LastIndexByte(s, ':'); i != -1 {
+		if n, err := strconv.Atoi(s[i+1:]); err == nil {
+			frame.File, frame.Line = s[:i], n
+			return
+		}
+	}
+	frame.File = s
+}
@@ -0,0 +1,182 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestParseGoroutineDump(t *testing.T) {
+	f, err := os.Open("testdata/panic.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	d, err := stacktrace.ParseGoroutineDump(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := d.Message, "panic: something went wrong [recovered]\n\tpanic: something went wrong"; got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+	if len(d.Goroutines) != 3 {
+		t.Fatalf("len(d.Goroutines) = %d, must be 3", len(d.Goroutines))
+	}
+
+	g := d.Goroutines[0]
+	if g.ID != 1 || g.State != "running" || g.Wait != 0 || g.LockedToThread || g.CreatedBy != nil {
+		t.Errorf("unexpected goroutine: %+v", g)
+	}
+	want := []stacktrace.GoroutineFrame{
+		{
+			Frame: stacktrace.Frame{
+				Function: "main.(*server).handle",
+				Package:  "main",
+				Receiver: "*server",
+				Name:     "handle",
+				File:     "/home/user/app/server.go",
+				Line:     42,
+			},
+			Args:   "0xc000012345, {0x4b1234, 0x5}",
+			Offset: 0x1d,
+		},
+		{
+			Frame: stacktrace.Frame{
+				Function: "main.process[...]",
+				Package:  "main",
+				Name:     "process[...]",
+				File:     "/home/user/app/process.go",
+				Line:     17,
+				Inlined:  true,
+			},
+			Args: "...",
+		},
+		{
+			Frame: stacktrace.Frame{
+				Function: "main.main",
+				Package:  "main",
+				Name:     "main",
+				File:     "/home/user/app/main.go",
+				Line:     9,
+			},
+			Offset: 0x25,
+		},
+	}
+	if !reflect.DeepEqual(g.Frames, want) {
+		t.Errorf("got=%+v want=%+v", g.Frames, want)
+	}
+
+	g = d.Goroutines[1]
+	if g.ID != 18 || g.State != "chan receive" || g.Wait != 5*time.Minute || !g.LockedToThread {
+		t.Errorf("unexpected goroutine: %+v", g)
+	}
+	if g.CreatedBy == nil || g.CreatedBy.Function != "main.worker" || g.CreatedBy.Line != 19 || g.CreatorID != 1 {
+		t.Errorf("unexpected created by: %+v %d", g.CreatedBy, g.CreatorID)
+	}
+	if got, want := g.Error(), "goroutine 18 [chan receive, 5 minutes, locked to thread]"; got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+
+	g = d.Goroutines[2]
+	if g.ID != 7 || g.State != "select" || !g.FramesElided || len(g.Frames) != 1 || g.CreatorID != 0 {
+		t.Errorf("unexpected goroutine: %+v", g)
+	}
+
+	entries := []string{
+		"## goroutine 1 [running]",
+		"/home/user/app/server.go:42 main.(*server).handle",
+		"/home/user/app/process.go:17 main.process[...]",
+		"/home/user/app/main.go:9 main.main",
+		"## goroutine 18 [chan receive, 5 minutes, locked to thread]",
+		"/home/user/app/worker.go:21 main.worker.func1",
+		"## created by",
+		"/home/user/app/worker.go:19 main.worker",
+		"## goroutine 7 [select]",
+		"/home/user/app/deep.go:5 main.deep",
+		"## created by",
+		"/home/user/app/main.go:8 main.main",
+	}
+	info := stacktrace.GetDebugInfo(d)
+	if info.Detail != d.Message {
+		t.Errorf("got=%q want=%q", info.Detail, d.Message)
+	}
+	if got := info.StackEntries; strings.Join(got, "\n") != strings.Join(entries, "\n") {
+		t.Errorf("got=%q want=%q", got, entries)
+	}
+
+	if got, want := stacktrace.Format(d.Goroutines[0]), strings.Join(append([]string{"goroutine 1 [running]"}, entries[1:4]...), "\n\t"); got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+
+	// The stacks of the creators are not StackTracers in the chain.
+	list := stacktrace.ListStackTracers(d)
+	if len(list) != len(d.Goroutines) {
+		t.Fatalf("len(list) = %d, must be %d", len(list), len(d.Goroutines))
+	}
+	for i, v := range list {
+		if v != d.Goroutines[i] {
+			t.Errorf("list[%d] = %v, must be %v", i, v, d.Goroutines[i])
+		}
+	}
+	if got := stacktrace.Origin(d); got != d.Goroutines[0] {
+		t.Errorf("got=%v want=%v", got, d.Goroutines[0])
+	}
+}
+
+func TestParseGoroutineDump_runtimeStack(t *testing.T) {
+	ready := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		close(ready)
+		<-done
+	}()
+	<-ready
+
+	buf := make([]byte, 1<<20)
+	buf = buf[:runtime.Stack(buf, true)]
+	d, err := stacktrace.ParseGoroutineDump(strings.NewReader(string(buf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Message != "" {
+		t.Errorf("got=%q", d.Message)
+	}
+
+	var found bool
+	for _, g := range d.Goroutines {
+		if len(g.Frames) == 0 || !strings.HasPrefix(g.Frames[len(g.Frames)-1].Function, "github.com/goaux/stacktrace/v2_test.TestParseGoroutineDump_runtimeStack.func") {
+			continue
+		}
+		found = true
+		if g.State != "chan receive" || !g.LockedToThread {
+			t.Errorf("unexpected goroutine: %+v", g)
+		}
+		if g.CreatedBy == nil || g.CreatedBy.Name != "TestParseGoroutineDump_runtimeStack" || g.CreatedBy.Line == 0 {
+			t.Errorf("unexpected created by: %+v", g.CreatedBy)
+		}
+		frames := stacktrace.Frames(g)
+		if len(frames) != len(g.Frames) || frames[0].String() != g.Frames[0].String() {
+			t.Errorf("got=%v want=%v", frames, g.Frames)
+		}
+	}
+	if !found {
+		t.Errorf("goroutine not found:\n%s", buf)
+	}
+}
+
+func TestParseGoroutineDump_empty(t *testing.T) {
+	_, err := stacktrace.ParseGoroutineDump(strings.NewReader("panic: boom\n"))
+	if !errors.Is(err, stacktrace.ErrNoGoroutine) {
+		t.Errorf("got=%v want=%v", err, stacktrace.ErrNoGoroutine)
+	}
+}
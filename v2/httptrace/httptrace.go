@@ -0,0 +1,283 @@
+// Package httptrace provides a net/http middleware that recovers the panics of
+// the handlers into traced errors and writes error responses.
+//
+// The [Handler] passes each error, with an opaque error ID, to a [Sink] for
+// logging, and writes a response according to the mode:
+//
+//   - In the development mode, a text or HTML page with the error message and
+//     the stack trace produced by [stacktrace.FormatOptions.Format].
+//   - Otherwise, an RFC 7807 "application/problem+json" body that contains
+//     only the status and the error ID, so that no detail of the error leaks.
+//
+// [HandlerFunc] adapts a function returning an error to an [http.Handler],
+// whose errors are handled in the same way as the panics.
+package httptrace
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+// Sink receives the errors handled by a [Handler], typically to log them.
+// id is the error ID included in the response.
+type Sink func(r *http.Request, id string, err error)
+
+// LogSink is the default Sink, which logs the error with its stack trace by
+// the standard logger of the log package.
+func LogSink(r *http.Request, id string, err error) {
+	log.Printf("httptrace: %s %s: error_id=%s: %s", r.Method, r.URL.Path, id, stacktrace.Format(err))
+}
+
+// Options are options for a [Handler].
+// A zero Options consists entirely of default values.
+type Options struct {
+	// Development selects the development mode, in which the response
+	// includes the message and the stack trace of the error. If false, the
+	// response includes only the status and the error ID.
+	Development bool
+
+	// Sink receives the errors. If nil, [LogSink] is used.
+	Sink Sink
+
+	// Status returns the status code of the response for err.
+	// If nil, or if it returns zero, 500 Internal Server Error is used.
+	Status func(err error) int
+
+	// NewID returns a new error ID. If nil, a random 128-bit hexadecimal
+	// string is used.
+	NewID func() string
+
+	// FormatOptions controls the stack trace in the development mode.
+	FormatOptions stacktrace.FormatOptions
+}
+
+// Handler is an [http.Handler] middleware that recovers the panics of another
+// handler into traced errors, passes them to the Sink, and writes the error
+// responses.
+//
+// The panic with [http.ErrAbortHandler] is not recovered, so that the server
+// aborts the response silently.
+//
+// If the wrapped handler has already written the response header, the error
+// is passed to the Sink, and the response is aborted by panicking with
+// http.ErrAbortHandler, as the server does for the panics without the
+// Handler, so that the client doesn't take the partial response for a
+// complete one.
+type Handler struct {
+	handler http.Handler
+	opts    Options
+}
+
+var _ http.Handler = (*Handler)(nil)
+
+// NewHandler returns a new Handler that wraps h.
+// If opts is nil, the default options are used.
+func NewHandler(h http.Handler, opts *Options) *Handler {
+	if opts == nil {
+		opts = &Options{}
+	}
+	return &Handler{handler: h, opts: *opts}
+}
+
+// Handler returns the handler wrapped by h.
+func (h *Handler) Handler() http.Handler {
+	return h.handler
+}
+
+// ServeHTTP calls the wrapped handler, and handles its panic if any.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw := &responseWriter{ResponseWriter: w, handler: h}
+	r = r.WithContext(context.WithValue(r.Context(), responseWriterKey{}, rw))
+	err := stacktrace.Catch(func() error {
+		h.handler.ServeHTTP(rw, r)
+		return nil
+	})
+	if err == nil {
+		return
+	}
+	if errors.Is(err, http.ErrAbortHandler) {
+		panic(http.ErrAbortHandler)
+	}
+	h.handleError(rw, r, err)
+}
+
+// handleError passes err to the Sink and writes the error response.
+func (h *Handler) handleError(w *responseWriter, r *http.Request, err error) {
+	id := h.newID()
+	sink := h.opts.Sink
+	if sink == nil {
+		sink = LogSink
+	}
+	sink(r, id, err)
+	if w.wroteHeader {
+		panic(http.ErrAbortHandler)
+	}
+	status := http.StatusInternalServerError
+	if h.opts.Status != nil {
+		if code := h.opts.Status(err); code != 0 {
+			status = code
+		}
+	}
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Set("X-Content-Type-Options", "nosniff")
+	if h.opts.Development {
+		h.writeDevelopment(w, r, status, id, err)
+		return
+	}
+	header.Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(Problem{
+		Type:    "about:blank",
+		Title:   http.StatusText(status),
+		Status:  status,
+		ErrorID: id,
+	})
+}
+
+// Problem is the body of the error response in the production mode, which is
+// a problem details object defined by RFC 7807 with the error ID as an
+// extension member.
+type Problem struct {
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Status  int    `json:"status"`
+	ErrorID string `json:"error_id"`
+}
+
+var developmentPage = template.Must(template.New("").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.Title}}</title>
+</head>
+<body>
+<h1>{{.Status}} {{.Title}}</h1>
+<p>Error ID: <code>{{.ID}}</code></p>
+<pre>{{.Trace}}</pre>
+</body>
+</html>
+`))
+
+// writeDevelopment writes the response in the development mode, which is an
+// HTML page if the client accepts it, or a text page otherwise.
+func (h *Handler) writeDevelopment(w http.ResponseWriter, r *http.Request, status int, id string, err error) {
+	trace := h.opts.FormatOptions.Format(err)
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		w.Write([]byte(http.StatusText(status) + "\nError ID: " + id + "\n\n" + trace + "\n"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	developmentPage.Execute(w, struct {
+		Status int
+		Title  string
+		ID     string
+		Trace  string
+	}{status, http.StatusText(status), id, trace})
+}
+
+func (h *Handler) newID() string {
+	if h.opts.NewID != nil {
+		return h.opts.NewID()
+	}
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// HandlerFunc is an adapter to allow the use of an ordinary function
+// returning an error as an [http.Handler].
+//
+// The error returned by the function is handled by the nearest [Handler]
+// wrapping it, in the same way as a panic. The Handler is found through the
+// context of the request, so the middlewares between them may replace the
+// ResponseWriter, but must keep the context derived from the one of the
+// Handler. If there is no Handler, the function is served by a Handler with
+// the default options.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// ServeHTTP calls f(w, r), and passes its error to the Handler.
+func (f HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rw, _ := r.Context().Value(responseWriterKey{}).(*responseWriter)
+	if rw == nil {
+		NewHandler(f, nil).ServeHTTP(w, r)
+		return
+	}
+	if err := f(w, r); err != nil {
+		rw.handler.handleError(rw, r, err)
+	}
+}
+
+// responseWriterKey is the context key of the responseWriter of the nearest
+// Handler.
+type responseWriterKey struct{}
+
+// responseWriter is the http.ResponseWriter passed by a Handler to the
+// wrapped handler, which records whether the header has been written.
+//
+// It implements http.Flusher, http.Hijacker and io.ReaderFrom regardless of
+// the underlying ResponseWriter, and they fail or fall back in the same way
+// as http.ResponseController does if it doesn't support them.
+type responseWriter struct {
+	http.ResponseWriter
+	handler     *Handler
+	wroteHeader bool
+}
+
+// WriteHeader records that the header has been written, unless code is
+// informational, and calls the underlying WriteHeader.
+func (w *responseWriter) WriteHeader(code int) {
+	if code >= 200 {
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records that the header has been written and calls the underlying
+// Write.
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush records that the header has been written and flushes the underlying
+// ResponseWriter, if it supports flushing.
+func (w *responseWriter) Flush() {
+	w.wroteHeader = true
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Hijack records that the header has been written, since no error response
+// can be written to the hijacked connection, and hijacks the connection of the
+// underlying ResponseWriter, if it supports hijacking.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.wroteHeader = true
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// ReadFrom records that the header has been written and copies src to the
+// underlying ResponseWriter, using its ReadFrom method if any.
+func (w *responseWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.wroteHeader = true
+	return io.Copy(w.ResponseWriter, src)
+}
+
+// Unwrap returns the underlying ResponseWriter, for [http.ResponseController].
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,271 @@
+package httptrace_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+	"github.com/goaux/stacktrace/v2/httptrace"
+)
+
+type sunk struct {
+	id  string
+	err error
+}
+
+func newOptions(development bool, list *[]sunk) *httptrace.Options {
+	return &httptrace.Options{
+		Development: development,
+		Sink: func(r *http.Request, id string, err error) {
+			*list = append(*list, sunk{id, err})
+		},
+		NewID: func() string { return "id1" },
+	}
+}
+
+func serve(h http.Handler, accept string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/path", nil)
+	if accept != "" {
+		r.Header.Set("Accept", accept)
+	}
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func panicking(w http.ResponseWriter, r *http.Request) {
+	panic("boom")
+}
+
+func TestHandler_production(t *testing.T) {
+	var list []sunk
+	w := serve(httptrace.NewHandler(http.HandlerFunc(panicking), newOptions(false, &list)), "")
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got=%d want=%d", w.Code, http.StatusInternalServerError)
+	}
+	if got, want := w.Header().Get("Content-Type"), "application/problem+json"; got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+	var p httptrace.Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	want := httptrace.Problem{Type: "about:blank", Title: "Internal Server Error", Status: 500, ErrorID: "id1"}
+	if p != want {
+		t.Errorf("got=%+v want=%+v", p, want)
+	}
+	if strings.Contains(w.Body.String(), "boom") {
+		t.Errorf("the body must not contain the detail: %s", w.Body)
+	}
+
+	if len(list) != 1 || list[0].id != "id1" {
+		t.Fatalf("unexpected sink: %+v", list)
+	}
+	if got, want := list[0].err.Error(), "panic: boom"; !strings.HasPrefix(got, want) {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+	if frames := stacktrace.Frames(list[0].err); len(frames) == 0 || frames[0].Name != "panicking" {
+		t.Errorf("the trace must start at panicking: %v", frames)
+	}
+}
+
+func TestHandler_development(t *testing.T) {
+	var list []sunk
+	h := httptrace.NewHandler(http.HandlerFunc(panicking), newOptions(true, &list))
+
+	t.Run("text", func(t *testing.T) {
+		w := serve(h, "")
+		if got, want := w.Header().Get("Content-Type"), "text/plain; charset=utf-8"; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		body := w.Body.String()
+		for _, s := range []string{"Error ID: id1", "panic: boom", "httptrace_test.go:"} {
+			if !strings.Contains(body, s) {
+				t.Errorf("the body must contain %q: %s", s, body)
+			}
+		}
+	})
+
+	t.Run("html", func(t *testing.T) {
+		w := serve(h, "text/html,application/xhtml+xml")
+		if got, want := w.Header().Get("Content-Type"), "text/html; charset=utf-8"; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		body := w.Body.String()
+		for _, s := range []string{"<code>id1</code>", "<pre>panic: boom", "httptrace_test.go:"} {
+			if !strings.Contains(body, s) {
+				t.Errorf("the body must contain %q: %s", s, body)
+			}
+		}
+	})
+}
+
+func TestHandler_wroteHeader(t *testing.T) {
+	var list []sunk
+	h := httptrace.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("boom")
+	}), newOptions(false, &list))
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("the response must be aborted: got=%v want=%v", v, http.ErrAbortHandler)
+		}
+		if len(list) != 1 {
+			t.Errorf("the error must be passed to the sink: %+v", list)
+		}
+	}()
+	serve(h, "")
+}
+
+func TestHandler_abort(t *testing.T) {
+	h := httptrace.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), nil)
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Errorf("got=%v want=%v", v, http.ErrAbortHandler)
+		}
+	}()
+	serve(h, "")
+}
+
+func TestHandlerFunc(t *testing.T) {
+	notFound := httptrace.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return stacktrace.Trace(os.ErrNotExist)
+	})
+	ok := httptrace.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		w.Write([]byte("ok"))
+		return nil
+	})
+
+	t.Run("Handler", func(t *testing.T) {
+		var list []sunk
+		opts := newOptions(false, &list)
+		opts.Status = func(err error) int {
+			if errors.Is(err, os.ErrNotExist) {
+				return http.StatusNotFound
+			}
+			return 0
+		}
+		mux := http.NewServeMux()
+		mux.Handle("/path", notFound)
+		w := serve(httptrace.NewHandler(mux, opts), "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("got=%d want=%d", w.Code, http.StatusNotFound)
+		}
+		if len(list) != 1 || !errors.Is(list[0].err, os.ErrNotExist) {
+			t.Errorf("unexpected sink: %+v", list)
+		}
+		if w := serve(httptrace.NewHandler(ok, opts), ""); w.Code != http.StatusOK || w.Body.String() != "ok" {
+			t.Errorf("unexpected response: %d %q", w.Code, w.Body)
+		}
+	})
+
+	t.Run("middleware", func(t *testing.T) {
+		var list []sunk
+		opts := newOptions(false, &list)
+		opts.Status = func(err error) int { return http.StatusNotFound }
+		// The middleware replaces the ResponseWriter without Unwrap.
+		middleware := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			notFound.ServeHTTP(struct{ http.ResponseWriter }{w}, r)
+		})
+		w := serve(httptrace.NewHandler(middleware, opts), "")
+		if w.Code != http.StatusNotFound {
+			t.Errorf("got=%d want=%d", w.Code, http.StatusNotFound)
+		}
+		if len(list) != 1 {
+			t.Errorf("the error must be passed to the sink of the Handler: %+v", list)
+		}
+	})
+
+	t.Run("without Handler", func(t *testing.T) {
+		w := serve(notFound, "")
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("got=%d want=%d", w.Code, http.StatusInternalServerError)
+		}
+		if got, want := w.Header().Get("Content-Type"), "application/problem+json"; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+}
+
+func TestResponseController(t *testing.T) {
+	h := httptrace.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Error(err)
+		}
+		if _, ok := w.(http.Flusher); !ok {
+			t.Error("w must be an http.Flusher")
+		}
+	}), nil)
+	if w := serve(h, ""); !w.Flushed {
+		t.Error("the response must be flushed")
+	}
+}
+
+func TestHijack(t *testing.T) {
+	h := httptrace.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("w must be an http.Hijacker")
+			return
+		}
+		conn, buf, err := hj.Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		buf.Flush()
+	}), nil)
+	server := httptest.NewServer(h)
+	defer server.Close()
+	res, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "hijacked" {
+		t.Errorf("got=%q want=%q", body, "hijacked")
+	}
+}
+
+func TestReaderFrom(t *testing.T) {
+	h := httptrace.NewHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rf, ok := w.(io.ReaderFrom)
+		if !ok {
+			t.Error("w must be an io.ReaderFrom")
+			return
+		}
+		if _, err := rf.ReadFrom(strings.NewReader("copied")); err != nil {
+			t.Error(err)
+		}
+	}), nil)
+	if w := serve(h, ""); w.Code != http.StatusOK || w.Body.String() != "copied" {
+		t.Errorf("unexpected response: %d %q", w.Code, w.Body)
+	}
+}
+
+func ExampleNewHandler() {
+	mux := http.NewServeMux()
+	mux.Handle("/items", httptrace.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return stacktrace.New("not implemented")
+	}))
+	handler := httptrace.NewHandler(mux, &httptrace.Options{
+		Development: os.Getenv("APP_ENV") == "development",
+	})
+	_ = http.ListenAndServe
+	_ = handler
+}
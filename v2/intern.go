@@ -0,0 +1,117 @@
+package stacktrace
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// internTable holds the interned stacks, keyed by their program counters.
+type internTable struct {
+	max int
+
+	mu     sync.RWMutex
+	stacks map[string]*internedStack
+}
+
+// internedStack is a slice of program counters shared by the errors captured
+// at the same call site through the same path, with its resolved frames.
+type internedStack struct {
+	callers []uintptr
+	symbols *symbols
+}
+
+var currentInternTable atomic.Pointer[internTable]
+
+// internBufferSize is the maximum number of the program counters of the
+// stacks to be interned. The deeper stacks are not interned.
+const internBufferSize = 64
+
+// SetInterning enables the interning of the stacks captured by [New],
+// [Trace] and its variants, and [Errorf], for the programs that capture the
+// identical stacks repeatedly, such as the ones that retry failing operations
+// in tight loops.
+//
+// The errors with the identical stack share the backing array of
+// [Error.Callers] and the frames resolved from it, which reduces the
+// allocations when capturing the stacks and the memory held by long-lived
+// errors, and the frames are resolved only once for each stack.
+//
+// At most maxStacks distinct stacks are interned; the stacks captured after
+// the limit is reached are not interned. The stacks deeper than 64 frames are
+// not interned either. If maxStacks is zero or negative, the interning is
+// disabled, which is the default.
+//
+// Calling SetInterning drops the stacks interned so far.
+func SetInterning(maxStacks int) {
+	if maxStacks <= 0 {
+		currentInternTable.Store(nil)
+		return
+	}
+	currentInternTable.Store(&internTable{
+		max:    maxStacks,
+		stacks: make(map[string]*internedStack),
+	})
+}
+
+// captureCallers returns the program counters of the stack of the caller,
+// skipping skip frames in the same way as Callers does, along with the shared
+// symbols for them if the stack is interned, or nil otherwise.
+func captureCallers(skip int) ([]uintptr, *symbols) {
+	t := currentInternTable.Load()
+	if t == nil {
+		return Callers(skip + 1), nil
+	}
+	var buf [internBufferSize]uintptr
+	n := runtime.Callers(skip+2, buf[:])
+	if n == len(buf) {
+		return Callers(skip + 1), nil
+	}
+	return t.intern(buf[:n])
+}
+
+// internCallers returns the interned copy of pc and its shared symbols, if
+// the interning is enabled. Otherwise, it returns a copy of pc and nil.
+// pc is not retained, so it may be reused by the caller.
+func internCallers(pc []uintptr) ([]uintptr, *symbols) {
+	if t := currentInternTable.Load(); t != nil {
+		return t.intern(pc)
+	}
+	return appendExact(nil, pc), nil
+}
+
+// intern returns the interned stack of pc and its shared symbols. If pc is not
+// interned yet, it interns a copy of pc, unless the table is full, in which
+// case it returns the copy and nil.
+// pc is not retained, so it may be reused by the caller.
+func (t *internTable) intern(pc []uintptr) ([]uintptr, *symbols) {
+	t.mu.RLock()
+	s := t.stacks[pcKey(pc)]
+	t.mu.RUnlock()
+	if s != nil {
+		return s.callers, s.symbols
+	}
+	callers := appendExact(nil, pc)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s := t.stacks[pcKey(pc)]; s != nil {
+		return s.callers, s.symbols
+	}
+	if len(t.stacks) >= t.max {
+		return callers, nil
+	}
+	s = &internedStack{callers: callers, symbols: new(symbols)}
+	t.stacks[strings.Clone(pcKey(callers))] = s
+	return s.callers, s.symbols
+}
+
+// pcKey returns the bytes of pc as a string without copying them, which is
+// valid only while pc is unchanged. It must be copied to be retained.
+func pcKey(pc []uintptr) string {
+	if len(pc) == 0 {
+		return ""
+	}
+	return unsafe.String((*byte)(unsafe.Pointer(&pc[0])), len(pc)*int(unsafe.Sizeof(pc[0])))
+}
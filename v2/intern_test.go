@@ -0,0 +1,104 @@
+package stacktrace_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func setInterning(t testing.TB, maxStacks int) {
+	t.Helper()
+	stacktrace.SetInterning(maxStacks)
+	t.Cleanup(func() { stacktrace.SetInterning(0) })
+}
+
+func traceInLoop(n int) []*stacktrace.Error {
+	list := make([]*stacktrace.Error, n)
+	for i := range list {
+		list[i] = stacktrace.Trace(os.ErrInvalid).(*stacktrace.Error)
+	}
+	return list
+}
+
+func sameArray(a, b []uintptr) bool {
+	return len(a) != 0 && len(a) == len(b) && &a[0] == &b[0]
+}
+
+func TestSetInterning(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		list := traceInLoop(2)
+		if sameArray(list[0].Callers, list[1].Callers) {
+			t.Error("Callers must not be shared")
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		setInterning(t, 10)
+		list := traceInLoop(3)
+		if !sameArray(list[0].Callers, list[1].Callers) || !sameArray(list[0].Callers, list[2].Callers) {
+			t.Error("Callers must be shared")
+		}
+		// An append to the shared Callers must not write into the shared array.
+		if c := list[0].Callers; cap(c) != len(c) {
+			t.Errorf("cap(Callers) = %d, must be %d", cap(c), len(c))
+		}
+		if got, want := list[1].Error(), list[0].Error(); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		if got, want := stacktrace.Format(list[2]), stacktrace.Format(list[0]); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+
+		// The other call site has another stack.
+		other := stacktrace.New("x").(*stacktrace.Error)
+		if sameArray(other.Callers, list[0].Callers) {
+			t.Error("Callers must not be shared")
+		}
+	})
+
+	t.Run("full", func(t *testing.T) {
+		setInterning(t, 1)
+		_ = traceInLoop(1)
+		list := [2]*stacktrace.Error{
+			stacktrace.New("x").(*stacktrace.Error),
+			stacktrace.New("x").(*stacktrace.Error),
+		}
+		if sameArray(list[0].Callers, list[1].Callers) {
+			t.Error("Callers must not be shared")
+		}
+	})
+
+	t.Run("allocs", func(t *testing.T) {
+		disabled := testing.AllocsPerRun(100, func() { _ = traceInLoop(1) })
+		setInterning(t, 10)
+		enabled := testing.AllocsPerRun(100, func() { _ = traceInLoop(1) })
+		if enabled >= disabled {
+			t.Errorf("enabled=%v must be less than disabled=%v", enabled, disabled)
+		}
+	})
+}
+
+func BenchmarkTrace_interned(b *testing.B) {
+	for _, tt := range []struct {
+		name      string
+		maxStacks int
+	}{
+		{"disabled", 0},
+		{"enabled", 100},
+	} {
+		b.Run(tt.name, func(b *testing.B) {
+			setInterning(b, tt.maxStacks)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = stacktrace.Trace(os.ErrInvalid)
+			}
+		})
+	}
+}
+
+func ExampleSetInterning() {
+	// Share the stacks of up to 1000 distinct call paths.
+	stacktrace.SetInterning(1000)
+	defer stacktrace.SetInterning(0)
+}
@@ -0,0 +1,57 @@
+// Package elfexe reads the Go specific metadata of ELF executables, shared by
+// the package stacktrace and the package symbolize.
+package elfexe
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"encoding/binary"
+	"errors"
+)
+
+// BuildID returns the Go build ID of f, or an empty string if f has no
+// .note.go.buildid section or if it is malformed.
+func BuildID(f *elf.File) (string, error) {
+	s := f.Section(".note.go.buildid")
+	if s == nil {
+		return "", nil
+	}
+	data, err := s.Data()
+	if err != nil {
+		return "", err
+	}
+	return parseGoBuildIDNote(data, f.ByteOrder), nil
+}
+
+// parseGoBuildIDNote returns the Go build ID in the contents of the
+// .note.go.buildid section, or an empty string if it is malformed.
+func parseGoBuildIDNote(data []byte, order binary.ByteOrder) string {
+	const tag = 4 // ELF_NOTE_GOBUILDID_TAG
+	if len(data) < 16 {
+		return ""
+	}
+	namesz := order.Uint32(data[0:])
+	descsz := order.Uint32(data[4:])
+	if namesz != 4 || order.Uint32(data[8:]) != tag || string(data[12:16]) != "Go\x00\x00" {
+		return ""
+	}
+	if uint32(len(data)-16) < descsz {
+		return ""
+	}
+	return string(data[16 : 16+descsz])
+}
+
+// SymTable returns the Go symbol table of f read from the .gopclntab section,
+// which is kept even if f was stripped with -ldflags=-s.
+func SymTable(f *elf.File) (*gosym.Table, error) {
+	pclntab := f.Section(".gopclntab")
+	text := f.Section(".text")
+	if pclntab == nil || text == nil {
+		return nil, errors.New("no .gopclntab section")
+	}
+	data, err := pclntab.Data()
+	if err != nil {
+		return nil, err
+	}
+	return gosym.NewTable(nil, gosym.NewLineTable(data, text.Addr))
+}
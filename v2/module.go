@@ -0,0 +1,52 @@
+package stacktrace
+
+import (
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+var mainModule struct {
+	once sync.Once
+	path string
+	pkg  string
+}
+
+func loadMainModule() {
+	mainModule.once.Do(func() {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			mainModule.path = info.Main.Path
+			mainModule.pkg = info.Path
+		}
+	})
+}
+
+// mainModulePath returns the module path of the main module, or an empty
+// string if it is not known.
+func mainModulePath() string {
+	loadMainModule()
+	return mainModule.path
+}
+
+// mainPackagePath returns the import path of the package main, or an empty
+// string if it is not known.
+func mainPackagePath() string {
+	loadMainModule()
+	return mainModule.pkg
+}
+
+// inMainModule reports whether the package pkg belongs to the main module.
+//
+// The package main and the external test packages of the main module are
+// considered to belong to the main module.
+func inMainModule(pkg string) bool {
+	if pkg == "main" {
+		return true
+	}
+	return inModule(strings.TrimSuffix(pkg, "_test"), mainModulePath())
+}
+
+// inModule reports whether the package pkg belongs to the module mod.
+func inModule(pkg, mod string) bool {
+	return mod != "" && (pkg == mod || strings.HasPrefix(pkg, mod+"/"))
+}
@@ -0,0 +1,7 @@
+//go:build !race
+
+package stacktrace_test
+
+// raceEnabled reports whether the race detector is enabled, which makes
+// sync.Pool drop some of the pooled values deliberately.
+const raceEnabled = false
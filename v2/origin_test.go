@@ -0,0 +1,49 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestOrigin_Latest(t *testing.T) {
+	inner := newTestTracer()
+	outer := stacktrace.NewError(fmt.Errorf("wrap: %w", inner), stacktrace.Callers(0))
+	a := newTestTracer()
+	b := newTestTracer()
+
+	tests := []struct {
+		name   string
+		err    error
+		origin error
+		latest error
+	}{
+		{"nil", nil, nil, nil},
+		{"no StackTracer", errors.New("x"), nil, nil},
+		{"single", inner, inner, inner},
+		{"wrapped", fmt.Errorf("x: %w", inner), inner, inner},
+		{"nested", outer, inner, outer},
+		{"join", errors.Join(a, fmt.Errorf("x: %w", b)), b, a},
+		{"join same depth", errors.Join(a, b), a, a},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stacktrace.Origin(tt.err); !sameError(got, tt.origin) {
+				t.Errorf("Origin: got=%v want=%v", got, tt.origin)
+			}
+			if got := stacktrace.Latest(tt.err); !sameError(got, tt.latest) {
+				t.Errorf("Latest: got=%v want=%v", got, tt.latest)
+			}
+		})
+	}
+}
+
+// sameError reports whether v is want, treating a nil StackTracer as a nil error.
+func sameError(v stacktrace.StackTracer, want error) bool {
+	if v == nil {
+		return want == nil
+	}
+	return error(v) == want
+}
@@ -0,0 +1,216 @@
+package stacktrace
+
+import (
+	"debug/elf"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/goaux/stacktrace/v2/internal/elfexe"
+)
+
+// RawDebugInfo is a serializable form of [DebugInfo] whose stack traces are
+// not symbolized yet.
+//
+// Getting a RawDebugInfo costs much less than getting a DebugInfo, because it
+// doesn't resolve the program counters into functions, files and lines.
+// The package github.com/goaux/stacktrace/v2/symbolize resolves them later,
+// possibly on another machine, using the executable file of the program,
+// and produces the same DebugInfo as [GetDebugInfo] would have produced.
+type RawDebugInfo struct {
+	// Detail provides a detailed error message.
+	Detail string `json:"detail,omitempty"`
+
+	// BuildID is the Go build ID of the executable file of the program.
+	// It is empty if it cannot be read.
+	BuildID string `json:"build_id,omitempty"`
+
+	// Base is the difference between the address where the executable was
+	// loaded and the address where it was linked, which is not zero for
+	// position independent executables. It is read from /proc/self/maps, and
+	// is zero on the systems without it.
+	Base uint64 `json:"base,omitempty"`
+
+	// Traces contains the stack traces related to the error.
+	Traces []RawTrace `json:"traces,omitempty"`
+}
+
+// RawTrace is an unsymbolized stack trace of a [StackTracer].
+type RawTrace struct {
+	// Heading is the entry that precedes the frames in the stack entries,
+	// such as "## message". It is empty if there is no such entry.
+	Heading string `json:"heading,omitempty"`
+
+	// Callers contains the program counters of the StackTracer.
+	Callers []uintptr `json:"callers,omitempty"`
+
+	// Frames contains the frames of the StackTracer that has no program
+	// counters, such as a goroutine captured by [TraceAll] or parsed by
+	// [ParseGoroutineDump], whose frames are known only by their names.
+	// Resolve uses them as they are.
+	Frames []Frame `json:"frames,omitempty"`
+
+	// Truncated reports whether Callers was truncated by the [SamplingPolicy].
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// GetRawDebugInfo extracts the debug information from an error in the same
+// way as [GetDebugInfo] does, without symbolizing the stack traces.
+//
+// The build ID and the base address are read once, when GetRawDebugInfo is
+// called first, from the headers of the executable file of the program and
+// /proc/self/maps, without reading its symbol tables.
+//
+// It returns a zero value if err is nil.
+func GetRawDebugInfo(err error) RawDebugInfo {
+	if err == nil {
+		return RawDebugInfo{}
+	}
+	exe := loadExecutableInfo()
+	info := RawDebugInfo{
+		Detail:  err.Error(),
+		BuildID: exe.buildID,
+		Base:    exe.base,
+	}
+	for _, s := range append(stackSections(err), snapshotSections(err)...) {
+		trace := RawTrace{Heading: s.heading}
+		if s.tracer != nil {
+			trace.Callers = s.tracer.StackTrace()
+			trace.Truncated = truncatedStack(s.tracer)
+			if trace.Callers == nil {
+				trace.Frames = resolvedFramesOf(s.tracer)
+			}
+		}
+		info.Traces = append(info.Traces, trace)
+	}
+	return info
+}
+
+// resolvedFramesOf returns the frames of v if v holds them without program
+// counters, or nil otherwise.
+func resolvedFramesOf(v StackTracer) []Frame {
+	if _, ok := v.(frameResolver); !ok {
+		return nil
+	}
+	var list []Frame
+	walkStackTracerFrames(v, func(frame *runtime.Frame) {
+		list = append(list, NewFrame(frame))
+	})
+	return list
+}
+
+// Resolve returns the DebugInfo that [GetDebugInfo] would have produced,
+// using frames to resolve the program counters of each trace.
+//
+// In the process in which raw was taken, [FramesOf] can be used as frames.
+// The package github.com/goaux/stacktrace/v2/symbolize provides the
+// resolver for the other processes.
+func (raw RawDebugInfo) Resolve(frames func(callers []uintptr) []Frame) DebugInfo {
+	info := DebugInfo{Detail: raw.Detail}
+	var prev []Frame
+	for _, trace := range raw.Traces {
+		if trace.Heading != "" {
+			info.StackEntries = append(info.StackEntries, trace.Heading)
+		}
+		list := trace.Frames
+		if list == nil {
+			list = frames(trace.Callers)
+		}
+		common := 0
+		if trace.Heading != "## created by" && trace.Frames == nil {
+			common = commonTail(len(list), len(prev), func(i, j int) bool {
+				a, b := &list[i], &prev[j]
+				return a.Function == b.Function && a.File == b.File && a.Line == b.Line
+			})
+			prev = list
+		}
+		for _, frame := range list[:len(list)-common] {
+			info.StackEntries = append(info.StackEntries, frame.String())
+		}
+		info.StackEntries = appendCommon(info.StackEntries, common)
+		if trace.Truncated {
+			info.StackEntries = append(info.StackEntries, truncatedEntry)
+		}
+	}
+	return info
+}
+
+type executableInfo struct {
+	buildID string
+	base    uint64
+}
+
+var executable struct {
+	once sync.Once
+	info executableInfo
+}
+
+func loadExecutableInfo() executableInfo {
+	executable.once.Do(func() {
+		executable.info = readExecutableInfo()
+	})
+	return executable.info
+}
+
+func readExecutableInfo() (info executableInfo) {
+	name, err := os.Executable()
+	if err != nil {
+		return
+	}
+	f, err := elf.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	info.buildID, _ = elfexe.BuildID(f)
+	info.base, _ = loadBase(f, uint64(anchorPC()))
+	return
+}
+
+// loadBase returns the base of the executable f from the mapping of the
+// process that contains pc, in the same way as pprof does: pc minus the
+// address where its file offset was linked. It reports false if the mapping is not known, for example on
+// the systems without /proc/self/maps.
+func loadBase(f *elf.File, pc uint64) (uint64, bool) {
+	data, err := os.ReadFile("/proc/self/maps")
+	if err != nil {
+		return 0, false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		// e.g. "55d0c0400000-55d0c0589000 r-xp 00001000 fd:01 1234 /path/to/exe"
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		addrs, offset := strings.SplitN(fields[0], "-", 2), fields[2]
+		if len(addrs) != 2 {
+			continue
+		}
+		start, err1 := strconv.ParseUint(addrs[0], 16, 64)
+		end, err2 := strconv.ParseUint(addrs[1], 16, 64)
+		off, err3 := strconv.ParseUint(offset, 16, 64)
+		if err1 != nil || err2 != nil || err3 != nil || pc < start || end <= pc {
+			continue
+		}
+		// The file offset of pc, and the address where it was linked.
+		off += pc - start
+		for _, prog := range f.Progs {
+			if prog.Type == elf.PT_LOAD && prog.Off <= off && off < prog.Off+prog.Filesz {
+				return pc - (prog.Vaddr + off - prog.Off), true
+			}
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// anchorPC returns a program counter in itself, which is used to compute the
+// base address of the executable.
+//
+//go:noinline
+func anchorPC() uintptr {
+	pc, _, _, _ := runtime.Caller(0)
+	return pc
+}
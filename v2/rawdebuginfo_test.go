@@ -0,0 +1,58 @@
+package stacktrace_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestGetRawDebugInfo(t *testing.T) {
+	ch := make(chan error)
+	go func() { ch <- stacktrace.New("inner") }()
+	err := errors.Join(stacktrace.New("outer"), <-ch)
+
+	raw := stacktrace.GetRawDebugInfo(err)
+	info := stacktrace.GetDebugInfo(err)
+	if raw.Detail != info.Detail {
+		t.Errorf("got=%q want=%q", raw.Detail, info.Detail)
+	}
+	if runtime.GOOS == "linux" && raw.BuildID == "" {
+		t.Error("BuildID must not be empty")
+	}
+
+	// Symbolizing the raw traces in process must produce the same entries.
+	entries := raw.Resolve(stacktrace.FramesOf).StackEntries
+	if !reflect.DeepEqual(entries, info.StackEntries) {
+		t.Errorf("got=%q want=%q", entries, info.StackEntries)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded stacktrace.RawDebugInfo
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, raw) {
+		t.Errorf("got=%+v want=%+v", decoded, raw)
+	}
+
+	t.Run("nil", func(t *testing.T) {
+		if raw := stacktrace.GetRawDebugInfo(nil); !reflect.DeepEqual(raw, stacktrace.RawDebugInfo{}) {
+			t.Errorf("raw must be zero: %+v", raw)
+		}
+	})
+
+	t.Run("untraced", func(t *testing.T) {
+		raw := stacktrace.GetRawDebugInfo(os.ErrInvalid)
+		if raw.Detail != os.ErrInvalid.Error() || raw.Traces != nil {
+			t.Errorf("raw must have no traces: %+v", raw)
+		}
+	})
+}
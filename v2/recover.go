@@ -0,0 +1,72 @@
+package stacktrace
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Recover converts a panic into an [*Error] and stores it in *errp.
+//
+// Recover must be deferred directly, like below:
+//
+//	func run() (err error) {
+//		defer stacktrace.Recover(&err)
+//		...
+//	}
+//
+// If the recovered value is an error, it is used as [Error.Err] as is, so
+// [errors.Is] and [errors.As] work with it. Otherwise, Err is an error whose
+// message is "panic: " followed by the value formatted with %v.
+//
+// The Callers of the Error start at the function that panicked, not at the
+// deferred call of Recover.
+//
+// If the goroutine is not panicking, Recover does nothing. This includes the
+// case where the goroutine is exiting by [runtime.Goexit]; Goexit continues
+// to terminate the goroutine.
+func Recover(errp *error) {
+	if v := recover(); v != nil {
+		*errp = newPanicError(v, Callers(1))
+	}
+}
+
+// Catch calls fn and returns its result. If fn panics, Catch returns the panic
+// converted into an [*Error] in the same way as [Recover].
+func Catch(fn func() error) (err error) {
+	defer Recover(&err)
+	return fn()
+}
+
+func newPanicError(v any, callers []uintptr) *Error {
+	err, ok := v.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", v)
+	}
+	return NewError(err, panicCallers(callers))
+}
+
+// panicCallers returns pc without the frames of the deferred calls and the
+// runtime functions that handle the panic, so that it starts at the function
+// that panicked.
+func panicCallers(pc []uintptr) []uintptr {
+	for i := range pc {
+		if funcName(pc[i]) != "runtime.gopanic" {
+			continue
+		}
+		for i++; i < len(pc); i++ {
+			if !strings.HasPrefix(funcName(pc[i]), "runtime.") {
+				return pc[i:]
+			}
+		}
+	}
+	return pc
+}
+
+func funcName(pc uintptr) string {
+	// pc is a return address; pc-1 is in the calling instruction.
+	if f := runtime.FuncForPC(pc - 1); f != nil {
+		return f.Name()
+	}
+	return ""
+}
@@ -0,0 +1,126 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+//go:noinline
+func panicValue(v any) {
+	panic(v)
+}
+
+//go:noinline
+func panicNil() int {
+	var p *int
+	return *p
+}
+
+func TestRecover(t *testing.T) {
+	t.Run("error", func(t *testing.T) {
+		err := func() (err error) {
+			defer stacktrace.Recover(&err)
+			panicValue(os.ErrInvalid)
+			return nil
+		}()
+		if !errors.Is(err, os.ErrInvalid) {
+			t.Errorf("err must be os.ErrInvalid: %v", err)
+		}
+		var v *stacktrace.Error
+		if !errors.As(err, &v) {
+			t.Fatalf("err must be *stacktrace.Error: %#v", err)
+		}
+		want := "invalid argument (recover_test.go:16 panicValue)"
+		if got := err.Error(); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+
+	t.Run("value", func(t *testing.T) {
+		err := func() (err error) {
+			defer stacktrace.Recover(&err)
+			panicValue(42)
+			return nil
+		}()
+		want := "panic: 42 (recover_test.go:16 panicValue)"
+		if err == nil || err.Error() != want {
+			t.Errorf("got=%v want=%q", err, want)
+		}
+	})
+
+	t.Run("runtime error", func(t *testing.T) {
+		err := func() (err error) {
+			defer stacktrace.Recover(&err)
+			panicNil()
+			return nil
+		}()
+		var re runtime.Error
+		if !errors.As(err, &re) {
+			t.Errorf("err must be runtime.Error: %#v", err)
+		}
+		frames := stacktrace.Frames(err)
+		if len(frames) == 0 || !strings.HasSuffix(frames[0].Function, ".panicNil") || frames[0].Line != 22 {
+			t.Errorf("frames must start at panicNil: %+v", frames)
+		}
+	})
+
+	t.Run("no panic", func(t *testing.T) {
+		err := func() (err error) {
+			defer stacktrace.Recover(&err)
+			return os.ErrExist
+		}()
+		if err != os.ErrExist {
+			t.Errorf("err must be os.ErrExist: %v", err)
+		}
+	})
+
+	t.Run("Goexit", func(t *testing.T) {
+		var wg sync.WaitGroup
+		returned := false
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = stacktrace.Catch(func() error {
+				runtime.Goexit()
+				return nil
+			})
+			returned = true
+		}()
+		wg.Wait()
+		if returned {
+			t.Error("Goexit must not be recovered")
+		}
+	})
+}
+
+func TestCatch(t *testing.T) {
+	err := stacktrace.Catch(func() error {
+		panicValue(os.ErrClosed)
+		return nil
+	})
+	if !errors.Is(err, os.ErrClosed) || !stacktrace.HasStackTracer(err) {
+		t.Errorf("err must be traced os.ErrClosed: %#v", err)
+	}
+
+	err = stacktrace.Catch(func() error { return os.ErrExist })
+	if err != os.ErrExist {
+		t.Errorf("err must be os.ErrExist: %v", err)
+	}
+}
+
+func ExampleRecover() {
+	run := func() (err error) {
+		defer stacktrace.Recover(&err)
+		var m map[string]int
+		m["answer"] = 42 // panics
+		return nil
+	}
+	err := run()
+	_ = stacktrace.Format(err)
+}
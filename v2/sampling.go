@@ -0,0 +1,120 @@
+package stacktrace
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SamplingPolicy limits the stacks captured by [New], [Trace] and its
+// variants, and [Errorf], for the situations such as error storms, where
+// capturing the stack of every error costs measurable CPU time.
+//
+// The errors are counted for each call site, that is the location where New,
+// Trace or Errorf is called, and the count is reset every Interval. The stacks
+// of the first First errors are captured fully, and thereafter the stack of
+// every Thereafter-th error. For the other errors, only the call site is
+// captured, and [Error.Truncated] is set, which [GetDebugInfo] renders as a
+// "... stack truncated by sampling" entry.
+//
+// Note that the [Fingerprint] of an error whose stack is truncated differs
+// from the one of the same error with the full stack.
+type SamplingPolicy struct {
+	// First is the number of the errors per call site per Interval whose
+	// stacks are captured fully.
+	First int
+
+	// Thereafter specifies that the stack of every Thereafter-th error after
+	// the first First errors is captured fully.
+	// If zero or negative, no more stacks are captured fully in the Interval.
+	Thereafter int
+
+	// Interval is the period in which the errors are counted.
+	// If zero or negative, one second is used.
+	Interval time.Duration
+}
+
+// sampler is the state of the SamplingPolicy in effect.
+type sampler struct {
+	policy   SamplingPolicy
+	interval int64
+	sites    sync.Map // map[uintptr]*siteCounter
+}
+
+// siteCounter counts the errors of a call site in the current interval.
+type siteCounter struct {
+	start atomic.Int64 // the start of the interval in Unix nanoseconds
+	n     atomic.Int64
+}
+
+var currentSampler atomic.Pointer[sampler]
+
+// SetSamplingPolicy sets the SamplingPolicy used by the whole program.
+// If p is nil, the sampling is disabled, and the stacks of all the errors are
+// captured fully, which is the default.
+//
+// Setting a policy resets the counts of the call sites.
+func SetSamplingPolicy(p *SamplingPolicy) {
+	if p == nil {
+		currentSampler.Store(nil)
+		return
+	}
+	s := &sampler{policy: *p, interval: int64(p.Interval)}
+	if s.interval <= 0 {
+		s.interval = int64(time.Second)
+	}
+	currentSampler.Store(s)
+}
+
+// sample reports whether the stack of the error at the call site pc is
+// captured fully.
+func (s *sampler) sample(pc uintptr) bool {
+	v, ok := s.sites.Load(pc)
+	if !ok {
+		v, _ = s.sites.LoadOrStore(pc, new(siteCounter))
+	}
+	c := v.(*siteCounter)
+	now := time.Now().UnixNano()
+	if start := c.start.Load(); now-start >= s.interval && c.start.CompareAndSwap(start, now) {
+		c.n.Store(0)
+	}
+	n := c.n.Add(1)
+	first := int64(s.policy.First)
+	if n <= first {
+		return true
+	}
+	k := int64(s.policy.Thereafter)
+	return k > 0 && (n-first)%k == 0
+}
+
+// sampledCallers returns the program counters of the stack of the caller,
+// skipping skip frames in the same way as Callers does, along with the shared
+// symbols for them if they are interned, and reports whether they were
+// truncated by the SamplingPolicy in effect.
+func sampledCallers(skip int) (callers []uintptr, shared *symbols, truncated bool) {
+	s := currentSampler.Load()
+	if s == nil {
+		callers, shared = captureCallers(skip + 1)
+		return callers, shared, false
+	}
+	var site [1]uintptr
+	if runtime.Callers(skip+2, site[:]) == 0 || s.sample(site[0]) {
+		callers, shared = captureCallers(skip + 1)
+		return callers, shared, false
+	}
+	callers, shared = internCallers(site[:])
+	return callers, shared, true
+}
+
+// truncatedStack reports whether the stack of v was truncated by the
+// SamplingPolicy.
+func truncatedStack(v StackTracer) bool {
+	switch v := v.(type) {
+	case *Error:
+		return v.Truncated
+	case Error:
+		return v.Truncated
+	}
+	return false
+}
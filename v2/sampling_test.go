@@ -0,0 +1,114 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func setSamplingPolicy(t *testing.T, p *stacktrace.SamplingPolicy) {
+	t.Helper()
+	stacktrace.SetSamplingPolicy(p)
+	t.Cleanup(func() { stacktrace.SetSamplingPolicy(nil) })
+}
+
+func TestSetSamplingPolicy(t *testing.T) {
+	setSamplingPolicy(t, &stacktrace.SamplingPolicy{First: 2, Thereafter: 3, Interval: time.Hour})
+
+	var truncated []bool
+	for i := 0; i < 10; i++ {
+		err := stacktrace.Trace(os.ErrInvalid)
+		var e *stacktrace.Error
+		if !errors.As(err, &e) {
+			t.Fatalf("err must be an *Error: %#v", err)
+		}
+		if e.Truncated && len(e.Callers) != 1 {
+			t.Errorf("len(e.Callers) = %d, must be 1", len(e.Callers))
+		}
+		truncated = append(truncated, e.Truncated)
+	}
+	want := []bool{false, false, true, true, false, true, true, false, true, true}
+	if !reflect.DeepEqual(truncated, want) {
+		t.Errorf("got=%v want=%v", truncated, want)
+	}
+
+	t.Run("call sites", func(t *testing.T) {
+		// Another call site has its own count.
+		if err := stacktrace.New("x").(*stacktrace.Error); err.Truncated {
+			t.Error("the first error of a call site must not be truncated")
+		}
+	})
+
+	t.Run("GetDebugInfo", func(t *testing.T) {
+		var err error
+		for i := 0; i < 3; i++ {
+			err = stacktrace.New("x")
+		}
+		entries := stacktrace.GetDebugInfo(err).StackEntries
+		if len(entries) != 2 || entries[1] != "... stack truncated by sampling" {
+			t.Errorf("unexpected entries: %q", entries)
+		}
+		if !strings.Contains(err.Error(), "TestSetSamplingPolicy") {
+			t.Errorf("the location must be kept: %q", err.Error())
+		}
+		raw := stacktrace.GetRawDebugInfo(err)
+		if len(raw.Traces) != 1 || !raw.Traces[0].Truncated {
+			t.Errorf("unexpected traces: %+v", raw.Traces)
+		}
+		if got := raw.Resolve(stacktrace.FramesOf).StackEntries; strings.Join(got, "\n") != strings.Join(entries, "\n") {
+			t.Errorf("got=%q want=%q", got, entries)
+		}
+	})
+
+	t.Run("Interval", func(t *testing.T) {
+		setSamplingPolicy(t, &stacktrace.SamplingPolicy{First: 1, Interval: time.Millisecond})
+		var last *stacktrace.Error
+		for i := 0; i < 2; i++ {
+			time.Sleep(2 * time.Millisecond)
+			last = stacktrace.New("x").(*stacktrace.Error)
+		}
+		if last.Truncated {
+			t.Error("the count must be reset after the interval")
+		}
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		stacktrace.SetSamplingPolicy(nil)
+		for i := 0; i < 3; i++ {
+			if stacktrace.New("x").(*stacktrace.Error).Truncated {
+				t.Fatal("the sampling must be disabled")
+			}
+		}
+	})
+}
+
+func BenchmarkTrace_sampled(b *testing.B) {
+	b.Run("disabled", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = stacktrace.Trace(os.ErrInvalid)
+		}
+	})
+	b.Run("sampled out", func(b *testing.B) {
+		stacktrace.SetSamplingPolicy(&stacktrace.SamplingPolicy{Interval: time.Hour})
+		defer stacktrace.SetSamplingPolicy(nil)
+		for i := 0; i < b.N; i++ {
+			_ = stacktrace.Trace(os.ErrInvalid)
+		}
+	})
+}
+
+func ExampleSetSamplingPolicy() {
+	// Capture the stacks of the first 10 errors per call site per second,
+	// and every 100th error thereafter.
+	stacktrace.SetSamplingPolicy(&stacktrace.SamplingPolicy{
+		First:      10,
+		Thereafter: 100,
+		Interval:   time.Second,
+	})
+	defer stacktrace.SetSamplingPolicy(nil)
+}
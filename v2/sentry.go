@@ -0,0 +1,162 @@
+package stacktrace
+
+import (
+	"runtime"
+	"strings"
+)
+
+// SentryEvent is an event of the Sentry event protocol describing an error,
+// returned by [NewSentryEvent]. Encode it with [encoding/json] to send it to
+// a server that speaks the protocol.
+//
+// Only the attributes derived from the error are set; the others, such as
+// EventID and Timestamp, are left to the caller.
+type SentryEvent struct {
+	// EventID is the hexadecimal UUID of the event without dashes.
+	EventID string `json:"event_id,omitempty"`
+
+	// Timestamp is the time of the event in RFC 3339 format.
+	Timestamp string `json:"timestamp,omitempty"`
+
+	// Platform is "go".
+	Platform string `json:"platform"`
+
+	// Level is "error".
+	Level string `json:"level"`
+
+	// Exception holds the errors of the chain.
+	Exception SentryExceptions `json:"exception"`
+
+	// Fingerprint is the [Fingerprint] of the error, which makes the server
+	// group the events by the code locations regardless of the messages.
+	// It is empty if the chain doesn't contain any StackTracer.
+	Fingerprint []string `json:"fingerprint,omitempty"`
+}
+
+// SentryExceptions is the exception interface of a [SentryEvent].
+type SentryExceptions struct {
+	// Values are the errors of the chain, from the innermost one, in the order
+	// the Sentry event protocol expects.
+	Values []SentryException `json:"values"`
+}
+
+// SentryException is an error in the chain of a [SentryEvent].
+type SentryException struct {
+	// Type is the Go dynamic type of the error qualified with its package
+	// path, e.g. "*io/fs.PathError", or "created by" for the stack of the
+	// creator of a goroutine.
+	Type string `json:"type"`
+
+	// Value is the message of the error.
+	Value string `json:"value"`
+
+	// Stacktrace is the stack trace of the error if it is a [StackTracer]
+	// with any frame, or nil otherwise.
+	Stacktrace *SentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+// SentryStacktrace is the stack trace of a [SentryException].
+type SentryStacktrace struct {
+	// Frames are the frames from the outermost, that is the oldest call.
+	Frames []SentryFrame `json:"frames"`
+}
+
+// SentryFrame is a frame of a [SentryStacktrace].
+type SentryFrame struct {
+	// Function is the function name without the package path,
+	// e.g. "(*Server).handle".
+	Function string `json:"function"`
+
+	// Module is the package path of the function.
+	Module string `json:"module,omitempty"`
+
+	// Filename is the file name as returned by [Frame.TrimmedFile].
+	Filename string `json:"filename,omitempty"`
+
+	// AbsPath is the file name on the machine where the program was built.
+	AbsPath string `json:"abs_path,omitempty"`
+
+	// Lineno is the line number.
+	Lineno int `json:"lineno,omitempty"`
+
+	// InApp reports whether the function belongs to the main module.
+	InApp bool `json:"in_app"`
+}
+
+// NewSentryEvent returns a Sentry event describing err.
+// It returns nil if err is nil.
+//
+// The event has an exception value for each error in err's chain, walked in
+// the same way as [ListStackTracers] does and reversed, so that err itself
+// comes last as the protocol expects. The values of the [StackTracer]s have
+// the stack traces, and the values of the other errors, such as the wrappers
+// created by [fmt.Errorf] and the root cause, have only the type and the
+// message.
+func NewSentryEvent(err error) *SentryEvent {
+	if err == nil {
+		return nil
+	}
+	var values []SentryException
+	walkErrorChain(err, 0, func(err error, _ int) bool {
+		stack, createdBy := ownStacks(err)
+		if stack == nil {
+			values = append(values, newSentryException(err, createdBy))
+		} else {
+			values = append(values, newSentryException(err, stack))
+			if createdBy != nil {
+				values = append(values, newSentryException(createdBy, createdBy))
+			}
+		}
+		return true
+	})
+	for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+		values[i], values[j] = values[j], values[i]
+	}
+	event := &SentryEvent{
+		Platform:  "go",
+		Level:     "error",
+		Exception: SentryExceptions{Values: values},
+	}
+	if s := Fingerprint(err); s != "" {
+		event.Fingerprint = []string{s}
+	}
+	return event
+}
+
+// newSentryException returns the exception value of err with the stack
+// trace of v, which is nil if err holds no stack.
+func newSentryException(err error, v StackTracer) SentryException {
+	e := SentryException{Type: exceptionType(err), Value: err.Error()}
+	if v == nil {
+		return e
+	}
+	if isCreatedBy(v) {
+		e.Type = "created by"
+	}
+	var frames []SentryFrame
+	walkStackTracerFrames(v, func(frame *runtime.Frame) {
+		pkg, recv, name := splitFunction(frame.Function)
+		switch {
+		case strings.HasPrefix(recv, "*"):
+			name = "(" + recv + ")." + name
+		case recv != "":
+			name = recv + "." + name
+		}
+		frames = append(frames, SentryFrame{
+			Function: name,
+			Module:   pkg,
+			Filename: trimFile(frame.File, pkg),
+			AbsPath:  frame.File,
+			Lineno:   frame.Line,
+			InApp:    inMainModule(pkg),
+		})
+	})
+	if len(frames) == 0 {
+		return e
+	}
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	e.Stacktrace = &SentryStacktrace{Frames: frames}
+	return e
+}
@@ -0,0 +1,89 @@
+package stacktrace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestNewSentryEvent(t *testing.T) {
+	t.Run("nil", func(t *testing.T) {
+		if got := stacktrace.NewSentryEvent(nil); got != nil {
+			t.Errorf("got=%v", got)
+		}
+	})
+
+	t.Run("golden", func(t *testing.T) {
+		f, err := os.Open("testdata/sentry.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+		dump, err := stacktrace.ParseGoroutineDump(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := json.MarshalIndent(stacktrace.NewSentryEvent(dump), "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile("testdata/sentry.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(append(got, '\n'), want) {
+			t.Errorf("got:\n%s\nwant:\n%s", got, want)
+		}
+	})
+
+	t.Run("traced", func(t *testing.T) {
+		_, file, line, _ := runtime.Caller(0)
+		err := fmt.Errorf("wrapped: %w", stacktrace.Trace(os.ErrNotExist))
+		event := stacktrace.NewSentryEvent(err)
+		values := event.Exception.Values
+		if len(values) != 3 {
+			t.Fatalf("got=%d want=%d", len(values), 3)
+		}
+		for i, want := range []string{"*errors.errorString", "*github.com/goaux/stacktrace/v2.Error", "*fmt.wrapError"} {
+			if values[i].Type != want {
+				t.Errorf("values[%d].Type: got=%q want=%q", i, values[i].Type, want)
+			}
+		}
+		if values[0].Stacktrace != nil || values[2].Stacktrace != nil {
+			t.Errorf("the untraced errors must not have a stacktrace: %+v", values)
+		}
+		if got, want := values[2].Value, err.Error(); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		frames := values[1].Stacktrace.Frames
+		want := stacktrace.SentryFrame{
+			Function: "TestNewSentryEvent.func3",
+			Module:   "github.com/goaux/stacktrace/v2_test",
+			Filename: "github.com/goaux/stacktrace/v2/sentry_test.go",
+			AbsPath:  file,
+			Lineno:   line + 1,
+			InApp:    true,
+		}
+		if got := frames[len(frames)-1]; got != want {
+			t.Errorf("the last frame must be the newest:\ngot=%+v\nwant=%+v", got, want)
+		}
+		if frames[0].InApp {
+			t.Errorf("the frames of the runtime must not be in app: %+v", frames[0])
+		}
+		if got, want := event.Fingerprint, []string{stacktrace.Fingerprint(err)}; len(got) != 1 || got[0] != want[0] {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+
+	t.Run("no StackTracer", func(t *testing.T) {
+		event := stacktrace.NewSentryEvent(os.ErrClosed)
+		if len(event.Exception.Values) != 1 || event.Fingerprint != nil {
+			t.Errorf("got=%+v", event)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+//go:build go1.21
+
+package stacktrace
+
+import (
+	"log/slog"
+	"runtime"
+	"strconv"
+)
+
+var (
+	_ slog.LogValuer = Error{}
+	_ slog.LogValuer = DebugInfo{}
+	_ slog.LogValuer = Frame{}
+)
+
+// LogValue implements [slog.LogValuer].
+//
+// It returns a group with the following attributes:
+//
+//   - msg: the result of err.Error()
+//   - frames: a group of the frames of err, keyed by their index
+//   - truncated: true, only if the stack was truncated by the [SamplingPolicy]
+//   - traces: a group of the other [StackTracer]s in err's chain and the stacks of
+//     the creators of the goroutines added by [Go] and [Group], keyed by their index,
+//     each of which is a group with msg, frames and truncated
+func (err Error) LogValue() slog.Value {
+	list := listStackTracers(err)
+	attrs := stackTracerAttrs(err)
+	if len(list) > 1 {
+		traces := make([]slog.Attr, 0, len(list)-1)
+		for i, v := range list[1:] {
+			traces = append(traces, slog.Attr{
+				Key:   strconv.Itoa(i),
+				Value: slog.GroupValue(stackTracerAttrs(v)...),
+			})
+		}
+		attrs = append(attrs, slog.Attr{Key: "traces", Value: slog.GroupValue(traces...)})
+	}
+	return slog.GroupValue(attrs...)
+}
+
+func stackTracerAttrs(v StackTracer) []slog.Attr {
+	var frames []slog.Attr
+	walkStackTracerFrames(v, func(frame *runtime.Frame) {
+		frames = append(frames, slog.Any(strconv.Itoa(len(frames)), NewFrame(frame)))
+	})
+	attrs := []slog.Attr{
+		slog.String("msg", v.Error()),
+		{Key: "frames", Value: slog.GroupValue(frames...)},
+	}
+	if truncatedStack(v) {
+		attrs = append(attrs, slog.Bool("truncated", true))
+	}
+	return attrs
+}
+
+// LogValue implements [slog.LogValuer].
+//
+// It returns a group with the following attributes:
+//
+//   - msg: the Detail
+//   - frames: a group of the StackEntries, keyed by their index
+func (info DebugInfo) LogValue() slog.Value {
+	frames := make([]slog.Attr, len(info.StackEntries))
+	for i, entry := range info.StackEntries {
+		frames[i] = slog.String(strconv.Itoa(i), entry)
+	}
+	return slog.GroupValue(
+		slog.String("msg", info.Detail),
+		slog.Attr{Key: "frames", Value: slog.GroupValue(frames...)},
+	)
+}
+
+// LogValue implements [slog.LogValuer].
+//
+// It returns a group with the function, file and line attributes.
+func (frame Frame) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("function", frame.Function),
+		slog.String("file", frame.File),
+		slog.Int("line", frame.Line),
+	)
+}
@@ -0,0 +1,90 @@
+//go:build go1.21
+
+package stacktrace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestError_slog(t *testing.T) {
+	ch := make(chan error)
+	go func() { ch <- stacktrace.New("inner") }()
+	inner := <-ch
+	err := stacktrace.NewError(fmt.Errorf("outer: %w", inner), stacktrace.Callers(0))
+	_, file, line, _ := runtime.Caller(0)
+
+	t.Run("json", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		log := slog.New(slog.NewJSONHandler(buf, nil))
+		log.Error("error", slog.Any("err", err))
+		var record struct {
+			Err struct {
+				Msg    string `json:"msg"`
+				Frames map[string]struct {
+					Function string `json:"function"`
+					File     string `json:"file"`
+					Line     int    `json:"line"`
+				} `json:"frames"`
+				Traces map[string]struct {
+					Msg string `json:"msg"`
+				} `json:"traces"`
+			} `json:"err"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if got, want := record.Err.Msg, err.Error(); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+		frame := record.Err.Frames["0"]
+		if frame.File != file || frame.Line != line-1 || !strings.HasSuffix(frame.Function, ".TestError_slog") {
+			t.Errorf("unexpected frame: %+v", frame)
+		}
+		if got, want := record.Err.Traces["0"].Msg, inner.Error(); got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+
+	t.Run("text", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		log := slog.New(slog.NewTextHandler(buf, nil))
+		log.Error("error", slog.Any("err", err))
+		txt := buf.String()
+		for _, want := range []string{
+			fmt.Sprintf(" err.frames.0.file=%s err.frames.0.line=%d ", file, line-1),
+			" err.traces.0.msg=",
+			" err.traces.0.frames.0.function=",
+		} {
+			if !strings.Contains(txt, want) {
+				t.Errorf("%q must contain %q", txt, want)
+			}
+		}
+	})
+
+	t.Run("single", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		log := slog.New(slog.NewTextHandler(buf, nil))
+		log.Error("error", slog.Any("err", inner))
+		if txt := buf.String(); strings.Contains(txt, "traces") {
+			t.Errorf("%q must not contain traces", txt)
+		}
+	})
+
+	t.Run("zero", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		log := slog.New(slog.NewTextHandler(buf, nil))
+		log.Error("error", slog.Any("err", stacktrace.NewError(errors.New("zero"), nil)))
+		if txt, want := buf.String(), " err.msg=zero\n"; !strings.HasSuffix(txt, want) {
+			t.Errorf("%q must end with %q", txt, want)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+//go:build go1.21
+
+// Package slogtrace provides a [slog.Handler] middleware that attaches stack
+// traces to log records automatically.
+//
+// The [Handler] inspects the attributes of every record, and if it finds an
+// error whose chain contains a [stacktrace.StackTracer], it adds a "stack"
+// attribute produced from [stacktrace.GetDebugInfo].
+package slogtrace
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+// StackKey is the key used by the Handler for the stack attribute.
+const StackKey = "stack"
+
+// HandlerOptions are options for a [Handler].
+// A zero HandlerOptions consists entirely of default values.
+type HandlerOptions struct {
+	// Level is the minimum level of the records to which the stack attribute
+	// is attached. If nil, the Handler uses [slog.LevelError].
+	Level slog.Leveler
+
+	// MaxFrames limits the number of the entries of the stack attribute.
+	// If zero or negative, all entries are included.
+	MaxFrames int
+
+	// CaptureLevel is the minimum level of the records for which the Handler
+	// captures a fresh stack when the record has no traced error at all.
+	// The captured stack starts at the caller of the logging method.
+	// It is independent of Level, which applies only to the traced errors.
+	// If nil, the Handler never captures a fresh stack.
+	CaptureLevel slog.Leveler
+}
+
+// Handler is a [slog.Handler] that attaches stack traces to the records and
+// passes them to another handler.
+type Handler struct {
+	handler slog.Handler
+	opts    HandlerOptions
+}
+
+var _ slog.Handler = (*Handler)(nil)
+
+// NewHandler returns a new Handler that wraps h.
+// If opts is nil, the default options are used.
+func NewHandler(h slog.Handler, opts *HandlerOptions) *Handler {
+	if opts == nil {
+		opts = &HandlerOptions{}
+	}
+	return &Handler{handler: h, opts: *opts}
+}
+
+// Handler returns the handler wrapped by h.
+func (h *Handler) Handler() slog.Handler {
+	return h.handler
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h *Handler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle adds the stack attribute to r if needed, and passes it to the wrapped handler.
+//
+// The stack attribute is produced from the first error in the attributes of r
+// whose chain contains a [stacktrace.StackTracer]. Attributes added by
+// [Handler.WithAttrs] are not inspected.
+func (h *Handler) Handle(ctx context.Context, r slog.Record) error {
+	if entries := h.stack(r); len(entries) != 0 {
+		if 0 < h.opts.MaxFrames && h.opts.MaxFrames < len(entries) {
+			entries = entries[:h.opts.MaxFrames]
+		}
+		r = r.Clone()
+		r.AddAttrs(slog.Any(StackKey, entries))
+	}
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *Handler) stack(r slog.Record) []string {
+	traceLevel := r.Level >= levelOf(h.opts.Level, slog.LevelError)
+	captureLevel := h.opts.CaptureLevel != nil && r.Level >= h.opts.CaptureLevel.Level()
+	if !traceLevel && !captureLevel {
+		// The attributes are not scanned for the records below both levels.
+		return nil
+	}
+	var traced error
+	r.Attrs(func(a slog.Attr) bool {
+		traced = findTracedError(a.Value)
+		return traced == nil
+	})
+	if traced != nil {
+		if !traceLevel {
+			return nil
+		}
+		return stacktrace.GetDebugInfo(traced).StackEntries
+	}
+	if !captureLevel {
+		return nil
+	}
+	callers := stacktrace.Callers(0)
+	for i, pc := range callers {
+		if pc == r.PC {
+			callers = callers[i:]
+			break
+		}
+	}
+	frames := stacktrace.FramesOf(callers)
+	entries := make([]string, len(frames))
+	for i, frame := range frames {
+		entries[i] = frame.String()
+	}
+	return entries
+}
+
+func levelOf(l slog.Leveler, def slog.Level) slog.Level {
+	if l == nil {
+		return def
+	}
+	return l.Level()
+}
+
+// findTracedError returns the first error in v, including the values in the
+// groups, whose chain contains a StackTracer.
+func findTracedError(v slog.Value) error {
+	switch v.Kind() {
+	case slog.KindAny, slog.KindLogValuer:
+		if err, ok := v.Any().(error); ok && stacktrace.HasStackTracer(err) {
+			return err
+		}
+	case slog.KindGroup:
+		for _, a := range v.Group() {
+			if err := findTracedError(a.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WithAttrs returns a new Handler whose wrapped handler is h's wrapped handler
+// with the given attributes.
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &Handler{handler: h.handler.WithAttrs(attrs), opts: h.opts}
+}
+
+// WithGroup returns a new Handler whose wrapped handler is h's wrapped handler
+// with the given group.
+func (h *Handler) WithGroup(name string) slog.Handler {
+	return &Handler{handler: h.handler.WithGroup(name), opts: h.opts}
+}
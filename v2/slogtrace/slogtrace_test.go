@@ -0,0 +1,177 @@
+//go:build go1.21
+
+package slogtrace_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+	"github.com/goaux/stacktrace/v2/slogtrace"
+)
+
+func newLogger(opts *slogtrace.HandlerOptions) (*slog.Logger, *bytes.Buffer) {
+	buf := new(bytes.Buffer)
+	h := slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(slogtrace.NewHandler(h, opts)), buf
+}
+
+func stackOf(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var record struct {
+		Stack []string `json:"stack"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	return record.Stack
+}
+
+// unwrapCounter is an error that counts the calls of its Unwrap method.
+type unwrapCounter struct {
+	n int
+}
+
+func (err *unwrapCounter) Error() string { return "unwrapCounter" }
+
+func (err *unwrapCounter) Unwrap() error {
+	err.n++
+	return nil
+}
+
+func TestHandler(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", stacktrace.Trace(os.ErrInvalid))
+	want := stacktrace.GetDebugInfo(err).StackEntries
+
+	t.Run("default", func(t *testing.T) {
+		log, buf := newLogger(nil)
+		log.Error("error", "err", err)
+		if got := stackOf(t, buf); !slices.Equal(got, want) {
+			t.Errorf("got=%v want=%v", got, want)
+		}
+		log.Warn("warn", "err", err)
+		if got := stackOf(t, buf); got != nil {
+			t.Errorf("stack must not be attached to warn: %v", got)
+		}
+	})
+
+	t.Run("level", func(t *testing.T) {
+		log, buf := newLogger(&slogtrace.HandlerOptions{Level: slog.LevelInfo})
+		log.Info("info", "err", err)
+		if got := stackOf(t, buf); !slices.Equal(got, want) {
+			t.Errorf("got=%v want=%v", got, want)
+		}
+		log.Debug("debug", "err", err)
+		if got := stackOf(t, buf); got != nil {
+			t.Errorf("stack must not be attached to debug: %v", got)
+		}
+	})
+
+	t.Run("group", func(t *testing.T) {
+		log, buf := newLogger(nil)
+		log.Error("error", slog.Group("req", slog.Int("id", 1), slog.Any("err", err)))
+		if got := stackOf(t, buf); !slices.Equal(got, want) {
+			t.Errorf("got=%v want=%v", got, want)
+		}
+	})
+
+	t.Run("logvaluer", func(t *testing.T) {
+		err := stacktrace.New("logvaluer")
+		log, buf := newLogger(nil)
+		log.Error("error", "err", err)
+		want := stacktrace.GetDebugInfo(err).StackEntries
+		if got := stackOf(t, buf); !slices.Equal(got, want) {
+			t.Errorf("got=%v want=%v", got, want)
+		}
+	})
+
+	t.Run("untraced", func(t *testing.T) {
+		log, buf := newLogger(nil)
+		log.Error("error", "err", errors.New("untraced"))
+		if got := stackOf(t, buf); got != nil {
+			t.Errorf("stack must not be attached: %v", got)
+		}
+	})
+
+	t.Run("MaxFrames", func(t *testing.T) {
+		log, buf := newLogger(&slogtrace.HandlerOptions{MaxFrames: 2})
+		log.Error("error", "err", err)
+		if got := stackOf(t, buf); !slices.Equal(got, want[:2]) {
+			t.Errorf("got=%v want=%v", got, want[:2])
+		}
+	})
+
+	t.Run("CaptureLevel", func(t *testing.T) {
+		log, buf := newLogger(&slogtrace.HandlerOptions{CaptureLevel: slog.LevelError})
+		log.Error("error")
+		got := stackOf(t, buf)
+		if len(got) == 0 || !strings.Contains(got[0], "slogtrace_test.go:") || !strings.HasSuffix(got[0], " TestHandler.func7") {
+			t.Errorf("stack must start at the caller of log.Error: %v", got)
+		}
+		log.Warn("warn")
+		if got := stackOf(t, buf); got != nil {
+			t.Errorf("stack must not be captured for warn: %v", got)
+		}
+	})
+
+	t.Run("CaptureLevel below Level", func(t *testing.T) {
+		log, buf := newLogger(&slogtrace.HandlerOptions{CaptureLevel: slog.LevelWarn})
+		log.Warn("warn")
+		got := stackOf(t, buf)
+		if len(got) == 0 || !strings.HasSuffix(got[0], " TestHandler.func8") {
+			t.Errorf("stack must be captured for warn: %v", got)
+		}
+		log.Warn("warn", "err", err)
+		if got := stackOf(t, buf); got != nil {
+			t.Errorf("stack must not be attached to warn: %v", got)
+		}
+	})
+
+	t.Run("below the levels", func(t *testing.T) {
+		log, buf := newLogger(nil)
+		err := &unwrapCounter{}
+		log.Info("info", "err", err)
+		if err.n != 0 {
+			t.Errorf("the attributes must not be scanned: %d", err.n)
+		}
+		buf.Reset()
+		log.Error("error", "err", err)
+		if err.n == 0 {
+			t.Error("the attributes must be scanned")
+		}
+	})
+
+	t.Run("WithAttrs", func(t *testing.T) {
+		log, buf := newLogger(nil)
+		log.With("a", 1).WithGroup("g").Error("error", "err", err)
+		var record struct {
+			A int `json:"a"`
+			G struct {
+				Stack []string `json:"stack"`
+			} `json:"g"`
+		}
+		if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+			t.Fatal(err)
+		}
+		if record.A != 1 || !slices.Equal(record.G.Stack, want) {
+			t.Errorf("unexpected record: %s", buf)
+		}
+	})
+}
+
+func ExampleNewHandler() {
+	h := slogtrace.NewHandler(slog.NewJSONHandler(os.Stderr, nil), &slogtrace.HandlerOptions{
+		Level:     slog.LevelError,
+		MaxFrames: 32,
+	})
+	log := slog.New(h)
+	log.Error("failed", "err", stacktrace.New("something went wrong"))
+}
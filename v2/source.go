@@ -0,0 +1,120 @@
+package stacktrace
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// SourceProvider provides the contents of the source files for the snippets
+// included by [FormatOptions].
+type SourceProvider interface {
+	// Source returns the contents of the source file.
+	// The file name is the one reported by [runtime.Frame].
+	Source(file string) ([]byte, error)
+}
+
+// SourceFunc is an adapter to allow the use of an ordinary function as a
+// [SourceProvider].
+type SourceFunc func(file string) ([]byte, error)
+
+// Source returns f(file).
+func (f SourceFunc) Source(file string) ([]byte, error) {
+	return f(file)
+}
+
+// OSSource is a [SourceProvider] that reads the source files from the local
+// file system, which is useful in the development environment.
+var OSSource SourceProvider = SourceFunc(os.ReadFile)
+
+// FSSource returns a [SourceProvider] that reads the source files from fsys,
+// such as an [embed.FS].
+//
+// The file names are converted into the paths in fsys by removing the leading
+// slash, and the volume name on Windows.
+func FSSource(fsys fs.FS) SourceProvider {
+	return SourceFunc(func(file string) ([]byte, error) {
+		name := strings.ReplaceAll(file, `\`, "/")
+		if i := strings.IndexByte(name, ':'); i != -1 && !strings.Contains(name[:i], "/") {
+			name = name[i+1:]
+		}
+		return fs.ReadFile(fsys, strings.TrimPrefix(path.Clean(name), "/"))
+	})
+}
+
+// SourceFrames selects the frames that are followed by the source code
+// snippets.
+type SourceFrames int
+
+const (
+	// SourceTopFrame selects the first frame of each stack trace.
+	SourceTopFrame SourceFrames = iota
+
+	// SourceMainModuleFrames selects all the frames of the functions in the
+	// main module, as reported by [runtime/debug.ReadBuildInfo].
+	SourceMainModuleFrames
+)
+
+// appendSnippet appends the source code snippet around the location of frame,
+// if it is selected and the source file is available.
+//
+// The snippet consists of an entry per line, such as "   9 | code", and the
+// line of the frame is marked as " > 10 | code".
+func (b *entriesBuilder) appendSnippet(frame *runtime.Frame, top bool) {
+	if b.o.Source == nil {
+		return
+	}
+	switch b.o.SourceFrames {
+	case SourceTopFrame:
+		if !top {
+			return
+		}
+	case SourceMainModuleFrames:
+		if pkg, _, _ := splitFunction(frame.Function); !inMainModule(pkg) {
+			return
+		}
+	}
+	lines := b.sourceLines(frame.File)
+	if frame.Line < 1 || len(lines) < frame.Line {
+		return
+	}
+	n := b.o.SourceLines
+	if n <= 0 {
+		n = 2
+	}
+	from, to := frame.Line-n, frame.Line+n
+	if from < 1 {
+		from = 1
+	}
+	if len(lines) < to {
+		to = len(lines)
+	}
+	width := len(strconv.Itoa(to))
+	for i := from; i <= to; i++ {
+		mark := "   "
+		if i == frame.Line {
+			mark = " > "
+		}
+		num := strconv.Itoa(i)
+		b.entries = append(b.entries,
+			mark+strings.Repeat(" ", width-len(num))+num+" | "+lines[i-1])
+	}
+}
+
+func (b *entriesBuilder) sourceLines(file string) []string {
+	if lines, ok := b.sources[file]; ok {
+		return lines
+	}
+	if b.sources == nil {
+		b.sources = map[string][]string{}
+	}
+	var lines []string
+	if data, err := b.o.Source.Source(file); err == nil {
+		lines = strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	}
+	b.sources[file] = lines
+	return lines
+}
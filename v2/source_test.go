@@ -0,0 +1,163 @@
+package stacktrace_test
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestFormatOptions_Source(t *testing.T) {
+	err := stacktrace.Trace(os.ErrInvalid)
+	frames := stacktrace.Frames(err)
+	all := stacktrace.GetDebugInfo(err).StackEntries
+	if len(all) != 3 || len(frames) != 3 {
+		t.Fatalf("len(all) = %d, must be 3: %q", len(all), all)
+	}
+	line := frames[0].Line
+
+	// numbered returns the source whose n-th line is "line n".
+	numbered := stacktrace.SourceFunc(func(file string) ([]byte, error) {
+		if file != frames[0].File {
+			return nil, os.ErrNotExist
+		}
+		var b strings.Builder
+		for i := 1; i <= line+100; i++ {
+			fmt.Fprintf(&b, "line %d\n", i)
+		}
+		return []byte(b.String()), nil
+	})
+	snippet := func(n int) []string {
+		var entries []string
+		for i := line - n; i <= line+n; i++ {
+			mark := "   "
+			if i == line {
+				mark = " > "
+			}
+			entries = append(entries, fmt.Sprintf("%s%*d | line %d", mark, len(fmt.Sprint(line+n)), i, i))
+		}
+		return entries
+	}
+	concat := func(lists ...[]string) []string {
+		var entries []string
+		for _, list := range lists {
+			entries = append(entries, list...)
+		}
+		return entries
+	}
+
+	tests := []struct {
+		name string
+		opts stacktrace.FormatOptions
+		want []string
+	}{
+		{
+			name: "default",
+			opts: stacktrace.FormatOptions{Source: numbered},
+			want: concat(all[:1], snippet(2), all[1:]),
+		},
+		{
+			name: "SourceLines",
+			opts: stacktrace.FormatOptions{Source: numbered, SourceLines: 1},
+			want: concat(all[:1], snippet(1), all[1:]),
+		},
+		{
+			name: "SourceMainModuleFrames",
+			opts: stacktrace.FormatOptions{Source: numbered, SourceFrames: stacktrace.SourceMainModuleFrames},
+			want: concat(all[:1], snippet(2), all[1:]),
+		},
+		{
+			name: "unreadable",
+			opts: stacktrace.FormatOptions{
+				Source: stacktrace.SourceFunc(func(string) ([]byte, error) { return nil, os.ErrNotExist }),
+			},
+			want: all,
+		},
+		{
+			name: "out of range",
+			opts: stacktrace.FormatOptions{
+				Source: stacktrace.SourceFunc(func(string) ([]byte, error) { return []byte("package x\n"), nil }),
+			},
+			want: all,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.opts.GetDebugInfo(err).StackEntries
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got=%q want=%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatOptions_Source_clamp(t *testing.T) {
+	err := stacktrace.Trace(os.ErrInvalid)
+	frame := stacktrace.Frames(err)[0]
+	o := stacktrace.FormatOptions{
+		Source: stacktrace.SourceFunc(func(string) ([]byte, error) {
+			return []byte(strings.Repeat("x\n", frame.Line-1) + "here"), nil
+		}),
+		SourceLines: frame.Line + 1,
+	}
+	entries := o.GetDebugInfo(err).StackEntries
+	if len(entries) < frame.Line+1 {
+		t.Fatalf("too short: %q", entries)
+	}
+	if got, want := entries[1], fmt.Sprintf("   %*d | x", len(fmt.Sprint(frame.Line)), 1); got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+	if got, want := entries[frame.Line], fmt.Sprintf(" > %d | here", frame.Line); got != want {
+		t.Errorf("got=%q want=%q", got, want)
+	}
+}
+
+func TestOSSource(t *testing.T) {
+	err := stacktrace.Trace(os.ErrInvalid) // marker
+	o := stacktrace.FormatOptions{Source: stacktrace.OSSource, SourceLines: 1}
+	entries := o.GetDebugInfo(err).StackEntries
+	if len(entries) < 4 {
+		t.Fatalf("too short: %q", entries)
+	}
+	if !strings.HasSuffix(entries[2], "err := stacktrace.Trace(os.ErrInvalid) // marker") ||
+		!strings.HasPrefix(entries[2], " > ") {
+		t.Errorf("unexpected snippet: %q", entries[1:4])
+	}
+}
+
+func TestFSSource(t *testing.T) {
+	fsys := fstest.MapFS{
+		"src/app/main.go": {Data: []byte("package main\n")},
+	}
+	tests := []struct {
+		file string
+		ok   bool
+	}{
+		{"/src/app/main.go", true},
+		{`C:\src\app\main.go`, true},
+		{"C:/src/app/main.go", true},
+		{"/src/app/other.go", false},
+	}
+	for _, tt := range tests {
+		data, err := stacktrace.FSSource(fsys).Source(tt.file)
+		if tt.ok && (err != nil || string(data) != "package main\n") {
+			t.Errorf("%s: data=%q err=%v", tt.file, data, err)
+		}
+		if !tt.ok && err == nil {
+			t.Errorf("%s: must fail", tt.file)
+		}
+	}
+}
+
+func ExampleFormatOptions_source() {
+	o := stacktrace.FormatOptions{
+		Source:       stacktrace.OSSource,
+		SourceLines:  3,
+		SourceFrames: stacktrace.SourceMainModuleFrames,
+	}
+	err := stacktrace.New("something went wrong")
+	_ = o.Format(err)
+}
@@ -8,38 +8,145 @@ type StackTracer interface {
 	error
 
 	// StackTrace returns a slice of program counters representing the call stack.
+	//
+	// It returns nil for the stacks that don't come from the program counters
+	// of the current process, such as the goroutines parsed by
+	// [ParseGoroutineDump] or captured by [TraceAll]. The functions of this
+	// package, such as [GetDebugInfo] and [Frames], still render their frames,
+	// but the ones that take program counters, such as [FramesOf], don't.
 	StackTrace() []uintptr
 }
 
 // HasStackTracer returns true if there is at least one StackTracer in the
 // error chain, false otherwise.
 func HasStackTracer(err error) bool {
+	// Walk the chain without errors.As, which allocates the target, unless
+	// an error in the chain has an As method.
+	hasAs := false
+	found := !walkErrorChain(err, 0, func(err error, _ int) bool {
+		if _, ok := err.(StackTracer); ok {
+			return false
+		}
+		if _, ok := err.(interface{ As(any) bool }); ok {
+			hasAs = true
+		}
+		return true
+	})
+	if found || !hasAs {
+		return found
+	}
 	var other StackTracer
 	return errors.As(err, &other)
 }
 
 // ListStackTracers returns all the StackTracers in the error chain.
+//
+// The chain is walked in pre-order, depth-first: an error comes before the
+// errors it wraps, and the errors returned by an Unwrap() []error method are
+// walked in order, each followed by its whole chain, in the same way as
+// [errors.As] does. Therefore the first one is the one errors.As would find.
 func ListStackTracers(err error) []StackTracer {
 	var list []StackTracer
-	walkErrorChain(err, func(err error) {
+	walkErrorChain(err, 0, func(err error, _ int) bool {
 		if v, ok := err.(StackTracer); ok {
 			list = append(list, v)
 		}
+		return true
+	})
+	return list
+}
+
+// creator is implemented by the errors in the chain that hold the stack of
+// the creator of a goroutine, such as the ones added by Go and Group, and the
+// goroutines of a GoroutineDump. creatorStack returns nil if the creator is
+// not known.
+//
+// The stacks of the creators are not StackTracers in the chain, so that they
+// don't hide the stack of the error from HasStackTracer, Origin, Latest and
+// errors.As; the renderers find them through this interface instead.
+type creator interface {
+	creatorStack() StackTracer
+}
+
+// ownStacks returns the stacks held by err itself, without unwrapping it:
+// err if it is a StackTracer, and the stack of the creator of the goroutine
+// if err is a creator that knows it. Each of them is nil if there is none.
+func ownStacks(err error) (stack, createdBy StackTracer) {
+	stack, _ = err.(StackTracer)
+	if c, ok := err.(creator); ok {
+		createdBy = c.creatorStack()
+	}
+	return stack, createdBy
+}
+
+// listStackTracers returns the StackTracers in err's chain in the same order
+// as ListStackTracers does, each followed by the stack of the creator of the
+// goroutine held by the same error, if any.
+func listStackTracers(err error) []StackTracer {
+	var list []StackTracer
+	walkErrorChain(err, 0, func(err error, _ int) bool {
+		stack, createdBy := ownStacks(err)
+		if stack != nil {
+			list = append(list, stack)
+		}
+		if createdBy != nil {
+			list = append(list, createdBy)
+		}
+		return true
 	})
 	return list
 }
 
-func walkErrorChain(err error, callback func(err error)) {
+// Latest returns the outermost StackTracer in err's chain, that is the one
+// [errors.As] would find and the first one [ListStackTracers] returns.
+// It returns nil if err's chain doesn't contain any StackTracer.
+func Latest(err error) StackTracer {
+	var latest StackTracer
+	walkErrorChain(err, 0, func(err error, _ int) bool {
+		latest, _ = err.(StackTracer)
+		return latest == nil
+	})
+	return latest
+}
+
+// Origin returns the deepest StackTracer in err's chain, that is the one
+// closest to the root cause, which is usually where the error occurred.
+// It returns nil if err's chain doesn't contain any StackTracer.
+//
+// The depth of an error is the number of the errors wrapping it. If the chain
+// is a tree made by Unwrap() []error methods and there are several deepest
+// StackTracers, the first one in the order of [ListStackTracers] is returned.
+func Origin(err error) StackTracer {
+	var origin StackTracer
+	maxDepth := -1
+	walkErrorChain(err, 0, func(err error, depth int) bool {
+		if v, ok := err.(StackTracer); ok && depth > maxDepth {
+			origin, maxDepth = v, depth
+		}
+		return true
+	})
+	return origin
+}
+
+// walkErrorChain calls callback for each error in err's chain in the order
+// described for [ListStackTracers], with its depth, until callback returns
+// false. It reports whether the walk was completed.
+func walkErrorChain(err error, depth int, callback func(err error, depth int) bool) bool {
 	if err == nil {
-		return
+		return true
+	}
+	if !callback(err, depth) {
+		return false
 	}
-	callback(err)
 	switch err := err.(type) {
 	case interface{ Unwrap() error }:
-		walkErrorChain(err.Unwrap(), callback)
+		return walkErrorChain(err.Unwrap(), depth+1, callback)
 	case interface{ Unwrap() []error }:
 		for _, err := range err.Unwrap() {
-			walkErrorChain(err, callback)
+			if !walkErrorChain(err, depth+1, callback) {
+				return false
+			}
 		}
 	}
+	return true
 }
@@ -0,0 +1,21 @@
+//go:build go1.23
+
+package stacktrace
+
+import "iter"
+
+// StackTracers returns an iterator sequence of the StackTracers in err's chain.
+//
+// The StackTracers are yielded in the same order as [ListStackTracers] returns
+// them, but the chain is walked lazily, and the walk stops when the iterator
+// function returns false.
+func StackTracers(err error) iter.Seq[StackTracer] {
+	return func(yield func(StackTracer) bool) {
+		walkErrorChain(err, 0, func(err error, _ int) bool {
+			if v, ok := err.(StackTracer); ok {
+				return yield(v)
+			}
+			return true
+		})
+	}
+}
@@ -2,7 +2,14 @@
 
 package stacktrace_test
 
-import "github.com/goaux/stacktrace/v2"
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+)
 
 func ExampleListStackTracers() {
 	var err error
@@ -12,3 +19,34 @@ func ExampleListStackTracers() {
 		}
 	}
 }
+
+func TestStackTracers(t *testing.T) {
+	a := newTestTracer()
+	b := newTestTracer()
+	err := stacktrace.NewError(errors.Join(a, fmt.Errorf("x: %w", b)), stacktrace.Callers(0))
+
+	var got []error
+	for v := range stacktrace.StackTracers(err) {
+		got = append(got, v)
+	}
+	want := []error{err, a, b}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v want=%v", got, want)
+	}
+	for i, v := range stacktrace.ListStackTracers(err) {
+		if error(v) != want[i] {
+			t.Errorf("ListStackTracers()[%d]: got=%v want=%v", i, v, want[i])
+		}
+	}
+
+	got = nil
+	for v := range stacktrace.StackTracers(err) {
+		got = append(got, v)
+		if len(got) == 2 {
+			break
+		}
+	}
+	if !reflect.DeepEqual(got, want[:2]) {
+		t.Errorf("got=%v want=%v", got, want[:2])
+	}
+}
@@ -1,6 +1,7 @@
 package stacktrace_test
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -45,7 +46,7 @@ func TestStackTracer(t *testing.T) {
 		if len(info.StackEntries) == 0 {
 			t.Errorf("err must have StackEntries")
 		}
-		want := "/stacktracer_test.go:18 newTestTracer\n"
+		want := "/stacktracer_test.go:19 newTestTracer\n"
 		got := info.Format()
 		if !strings.Contains(got, want) {
 			t.Errorf("got=%q", got)
@@ -77,3 +78,38 @@ func TestStackTracer(t *testing.T) {
 		check(t, err)
 	})
 }
+
+// asTracer is an error that converts itself into a StackTracer by As.
+type asTracer struct{}
+
+func (asTracer) Error() string { return "asTracer" }
+
+func (asTracer) As(target any) bool {
+	if p, ok := target.(*stacktrace.StackTracer); ok {
+		*p = newTestTracer().(stacktrace.StackTracer)
+		return true
+	}
+	return false
+}
+
+func TestHasStackTracer(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"plain", errors.New("x"), false},
+		{"traced", stacktrace.New("x"), true},
+		{"wrapped", fmt.Errorf("x: %w", newTestTracer()), true},
+		{"joined", errors.Join(errors.New("x"), newTestTracer()), true},
+		{"As method", fmt.Errorf("x: %w", asTracer{}), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stacktrace.HasStackTracer(tt.err); got != tt.want {
+				t.Errorf("got=%v want=%v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package symbolize
+
+import (
+	"debug/dwarf"
+	"sort"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+// dwarfResolver resolves program counters using the DWARF debug information.
+type dwarfResolver struct {
+	data  *dwarf.Data
+	funcs []*dwarfFunc // sorted by low
+}
+
+// dwarfFunc is the concrete instance of a function, covering [low, high).
+type dwarfFunc struct {
+	low, high uint64
+	name      string
+	origin    dwarf.Offset
+	unit      *dwarfUnit
+	inlines   []*dwarfInline
+}
+
+// dwarfInline is an inlined instance of a function.
+type dwarfInline struct {
+	ranges   [][2]uint64
+	name     string
+	origin   dwarf.Offset
+	children []*dwarfInline
+}
+
+// dwarfUnit is a compilation unit, whose line table is read lazily.
+type dwarfUnit struct {
+	entry *dwarf.Entry
+	lines *dwarf.LineReader
+}
+
+func newDWARFResolver(d *dwarf.Data) (*dwarfResolver, error) {
+	r := &dwarfResolver{data: d}
+	names := map[dwarf.Offset]string{}
+	rd := d.Reader()
+	var unit *dwarfUnit
+	for {
+		e, err := rd.Next()
+		if err != nil {
+			return nil, err
+		}
+		if e == nil {
+			break
+		}
+		switch e.Tag {
+		case dwarf.TagCompileUnit:
+			unit = &dwarfUnit{entry: e}
+			continue // read the children
+		case dwarf.TagSubprogram:
+			if name, ok := e.Val(dwarf.AttrName).(string); ok {
+				names[e.Offset] = name
+			}
+			ranges, err := d.Ranges(e)
+			if err != nil {
+				return nil, err
+			}
+			if len(ranges) == 0 || unit == nil {
+				break
+			}
+			fn := &dwarfFunc{unit: unit}
+			fn.name, _ = e.Val(dwarf.AttrName).(string)
+			fn.origin, _ = e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+			if e.Children {
+				if fn.inlines, err = readInlines(d, rd); err != nil {
+					return nil, err
+				}
+			}
+			for _, rng := range ranges {
+				f := *fn
+				f.low, f.high = rng[0], rng[1]
+				r.funcs = append(r.funcs, &f)
+			}
+			continue // the children are already read
+		}
+		if e.Children {
+			rd.SkipChildren()
+		}
+	}
+	for _, fn := range r.funcs {
+		if fn.name == "" {
+			fn.name = names[fn.origin]
+		}
+		resolveInlineNames(fn.inlines, names)
+	}
+	sort.Slice(r.funcs, func(i, j int) bool {
+		return r.funcs[i].low < r.funcs[j].low
+	})
+	return r, nil
+}
+
+// readInlines reads the children of the current entry of rd, and returns the
+// inlined subroutines among them.
+func readInlines(d *dwarf.Data, rd *dwarf.Reader) ([]*dwarfInline, error) {
+	var list []*dwarfInline
+	for {
+		e, err := rd.Next()
+		if err != nil {
+			return nil, err
+		}
+		if e == nil || e.Tag == 0 {
+			return list, nil
+		}
+		if e.Tag != dwarf.TagInlinedSubroutine {
+			if e.Children {
+				rd.SkipChildren()
+			}
+			continue
+		}
+		ranges, err := d.Ranges(e)
+		if err != nil {
+			return nil, err
+		}
+		in := &dwarfInline{ranges: ranges}
+		in.origin, _ = e.Val(dwarf.AttrAbstractOrigin).(dwarf.Offset)
+		if e.Children {
+			if in.children, err = readInlines(d, rd); err != nil {
+				return nil, err
+			}
+		}
+		list = append(list, in)
+	}
+}
+
+func resolveInlineNames(list []*dwarfInline, names map[dwarf.Offset]string) {
+	for _, in := range list {
+		in.name = names[in.origin]
+		resolveInlineNames(in.children, names)
+	}
+}
+
+func (r *dwarfResolver) frame(pc uint64) (stacktrace.Frame, bool) {
+	i := sort.Search(len(r.funcs), func(i int) bool {
+		return pc < r.funcs[i].low
+	}) - 1
+	if i < 0 || r.funcs[i].high <= pc {
+		return stacktrace.Frame{}, false
+	}
+	fn := r.funcs[i]
+	name, inlined := fn.name, false
+	for list := fn.inlines; ; {
+		in := findInline(list, pc)
+		if in == nil {
+			break
+		}
+		name, inlined, list = in.name, true, in.children
+	}
+	file, line := fn.unit.lineAt(r.data, pc)
+	return newFrame(name, file, line, fn.low, inlined), true
+}
+
+func findInline(list []*dwarfInline, pc uint64) *dwarfInline {
+	for _, in := range list {
+		for _, rng := range in.ranges {
+			if rng[0] <= pc && pc < rng[1] {
+				return in
+			}
+		}
+	}
+	return nil
+}
+
+func (u *dwarfUnit) lineAt(d *dwarf.Data, pc uint64) (string, int) {
+	if u.lines == nil {
+		lr, err := d.LineReader(u.entry)
+		if err != nil || lr == nil {
+			return "", 0
+		}
+		u.lines = lr
+	}
+	var entry dwarf.LineEntry
+	if err := u.lines.SeekPC(pc, &entry); err != nil || entry.File == nil {
+		return "", 0
+	}
+	return entry.File.Name, entry.Line
+}
@@ -0,0 +1,32 @@
+package symbolize
+
+import (
+	"debug/elf"
+	"debug/gosym"
+
+	"github.com/goaux/stacktrace/v2"
+	"github.com/goaux/stacktrace/v2/internal/elfexe"
+)
+
+// gosymResolver resolves program counters using the Go symbol table, which is
+// available even if the executable was built without the DWARF debug
+// information.
+type gosymResolver struct {
+	table *gosym.Table
+}
+
+func newGosymResolver(f *elf.File) (*gosymResolver, error) {
+	table, err := elfexe.SymTable(f)
+	if err != nil {
+		return nil, err
+	}
+	return &gosymResolver{table: table}, nil
+}
+
+func (r *gosymResolver) frame(pc uint64) (stacktrace.Frame, bool) {
+	file, line, fn := r.table.PCToLine(pc)
+	if fn == nil {
+		return stacktrace.Frame{}, false
+	}
+	return newFrame(fn.Name, file, line, fn.Entry, false), true
+}
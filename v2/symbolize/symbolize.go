@@ -0,0 +1,161 @@
+// Package symbolize resolves the program counters of a
+// [stacktrace.RawDebugInfo] into functions, files and lines offline, using
+// the executable file of the program that produced it.
+//
+// It produces the same [stacktrace.DebugInfo] as [stacktrace.GetDebugInfo]
+// would have produced in the program. Only ELF executables are supported.
+//
+// The DWARF debug information of the executable is used to resolve the frames
+// of inlined functions. If the executable was built without it, for example
+// with -ldflags=-w, the Go symbol table is used instead, and the frames of
+// inlined functions are reported as a part of their callers.
+package symbolize
+
+import (
+	"debug/elf"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/goaux/stacktrace/v2"
+	"github.com/goaux/stacktrace/v2/internal/elfexe"
+)
+
+// ErrBuildIDMismatch is returned when the build ID of a RawDebugInfo doesn't
+// match the one of the executable file.
+var ErrBuildIDMismatch = errors.New("symbolize: build ID mismatch")
+
+// Binary is an executable file used to symbolize program counters.
+//
+// A Binary is safe for concurrent use.
+type Binary struct {
+	closer  io.Closer
+	buildID string
+
+	mu       sync.Mutex
+	resolver resolver
+}
+
+// resolver resolves a link-time program counter into the innermost frame at
+// the location. It returns false if pc is unknown.
+type resolver interface {
+	frame(pc uint64) (stacktrace.Frame, bool)
+}
+
+// Open opens the named executable file for symbolization.
+func Open(name string) (*Binary, error) {
+	f, err := elf.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := newBinary(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	b.closer = f
+	return b, nil
+}
+
+// NewBinary returns a Binary that reads the executable file from r.
+//
+// The caller is responsible for keeping r available until the Binary is no
+// longer used.
+func NewBinary(r io.ReaderAt) (*Binary, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return nil, err
+	}
+	return newBinary(f)
+}
+
+func newBinary(f *elf.File) (*Binary, error) {
+	b := &Binary{}
+	buildID, err := elfexe.BuildID(f)
+	if err != nil {
+		return nil, fmt.Errorf("symbolize: read build ID: %w", err)
+	}
+	b.buildID = buildID
+	if d, err := f.DWARF(); err == nil {
+		r, err := newDWARFResolver(d)
+		if err != nil {
+			return nil, fmt.Errorf("symbolize: read DWARF: %w", err)
+		}
+		b.resolver = r
+		return b, nil
+	}
+	r, err := newGosymResolver(f)
+	if err != nil {
+		return nil, fmt.Errorf("symbolize: read Go symbol table: %w", err)
+	}
+	b.resolver = r
+	return b, nil
+}
+
+// Close closes the underlying file if the Binary was created by [Open].
+func (b *Binary) Close() error {
+	if b.closer == nil {
+		return nil
+	}
+	return b.closer.Close()
+}
+
+// BuildID returns the Go build ID of the executable file.
+// It is empty if the executable file has no build ID.
+func (b *Binary) BuildID() string {
+	return b.buildID
+}
+
+// DebugInfo resolves the program counters of raw and returns the
+// [stacktrace.DebugInfo] that [stacktrace.GetDebugInfo] would have produced
+// in the program.
+//
+// It returns [ErrBuildIDMismatch] if both raw and the executable file have a
+// build ID and they are different.
+func (b *Binary) DebugInfo(raw stacktrace.RawDebugInfo) (stacktrace.DebugInfo, error) {
+	if raw.BuildID != "" && b.buildID != "" && raw.BuildID != b.buildID {
+		return stacktrace.DebugInfo{}, fmt.Errorf("%w: %q != %q", ErrBuildIDMismatch, raw.BuildID, b.buildID)
+	}
+	return raw.Resolve(func(callers []uintptr) []stacktrace.Frame {
+		return b.Frames(callers, raw.Base)
+	}), nil
+}
+
+// Frames resolves callers, the program counters returned by [runtime.Callers]
+// in a process whose base address is base, into frames.
+//
+// Like [stacktrace.FramesOf], it stops at main.main.
+func (b *Binary) Frames(callers []uintptr, base uint64) []stacktrace.Frame {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var list []stacktrace.Frame
+	for _, pc := range callers {
+		// runtime.Callers returns a program counter for each frame, including
+		// the frames of inlined functions. Each program counter is the return
+		// address; pc-1 is in the instruction in question.
+		frame, ok := b.resolver.frame(uint64(pc) - base - 1)
+		frame.PC = pc - 1
+		if !ok {
+			frame = stacktrace.Frame{PC: pc}
+		}
+		list = append(list, frame)
+		if frame.Function == "main.main" {
+			break
+		}
+	}
+	return list
+}
+
+// newFrame returns a Frame for the location.
+func newFrame(function, file string, line int, entry uint64, inlined bool) stacktrace.Frame {
+	frame := stacktrace.NewFrame(&runtime.Frame{
+		Function: function,
+		File:     file,
+		Line:     line,
+		Entry:    uintptr(entry),
+	})
+	frame.Inlined = inlined
+	return frame
+}
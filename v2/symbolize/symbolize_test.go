@@ -0,0 +1,112 @@
+package symbolize_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/goaux/stacktrace/v2"
+	"github.com/goaux/stacktrace/v2/symbolize"
+)
+
+type result struct {
+	Raw  stacktrace.RawDebugInfo `json:"raw"`
+	Info stacktrace.DebugInfo    `json:"info"`
+}
+
+// buildProg builds and runs testdata/prog with the given build flags,
+// and returns the path of the executable and its output.
+func buildProg(t *testing.T, flags ...string) (string, result) {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("ELF executables are supported only")
+	}
+	gocmd, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command is not available")
+	}
+	exe := filepath.Join(t.TempDir(), "prog")
+	args := append(append([]string{"build", "-o", exe}, flags...), "./testdata/prog")
+	if out, err := exec.Command(gocmd, args...).CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, out)
+	}
+	out, err := exec.Command(exe).Output()
+	if err != nil {
+		t.Fatalf("prog: %v", err)
+	}
+	var r result
+	if err := json.Unmarshal(out, &r); err != nil {
+		t.Fatalf("%v: %s", err, out)
+	}
+	return exe, r
+}
+
+func TestBinary_DebugInfo(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags []string
+	}{
+		{name: "dwarf"},
+		{name: "pie", flags: []string{"-buildmode=pie"}},
+		{name: "stripped", flags: []string{"-ldflags=-s -w", "-gcflags=all=-l"}},
+		{name: "stripped pie", flags: []string{"-buildmode=pie", "-ldflags=-s -w", "-gcflags=all=-l"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exe, r := buildProg(t, tt.flags...)
+			if r.Raw.BuildID == "" {
+				t.Error("BuildID must not be empty")
+			}
+			b, err := symbolize.Open(exe)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer b.Close()
+			if got, want := b.BuildID(), r.Raw.BuildID; got != want {
+				t.Errorf("got=%q want=%q", got, want)
+			}
+			info, err := b.DebugInfo(r.Raw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(info, r.Info) {
+				t.Errorf("got:\n%s\nwant:\n%s", info.Format(), r.Info.Format())
+			}
+			if !strings.Contains(r.Info.Format(), "## created by") {
+				t.Errorf("prog must produce the created by section:\n%s", r.Info.Format())
+			}
+			if !strings.Contains(r.Info.Format(), " in common with previous") {
+				t.Errorf("prog must produce the frames in common:\n%s", r.Info.Format())
+			}
+		})
+	}
+}
+
+func TestBinary_DebugInfo_mismatch(t *testing.T) {
+	exe, r := buildProg(t)
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := symbolize.NewBinary(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Raw.BuildID = "other"
+	if _, err := b.DebugInfo(r.Raw); !errors.Is(err, symbolize.ErrBuildIDMismatch) {
+		t.Errorf("err must be ErrBuildIDMismatch: %v", err)
+	}
+}
+
+func TestOpen(t *testing.T) {
+	if _, err := symbolize.Open("testdata/prog/main.go"); err == nil {
+		t.Error("err must not be nil for a non ELF file")
+	}
+}
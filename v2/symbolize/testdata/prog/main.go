@@ -0,0 +1,52 @@
+// Command prog prints the RawDebugInfo and the DebugInfo of an error as JSON,
+// for testing the symbolize package.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+type result struct {
+	Raw  stacktrace.RawDebugInfo `json:"raw"`
+	Info stacktrace.DebugInfo    `json:"info"`
+}
+
+// inlined is small enough to be inlined.
+func inlined() error {
+	return stacktrace.New("inlined")
+}
+
+//go:noinline
+func outlined() error {
+	return inlined()
+}
+
+// wrapped wraps an error that is already traced with another trace, whose
+// frames are partly in common with the one of the wrapped error.
+//
+//go:noinline
+func wrapped() error {
+	return stacktrace.NewError(fmt.Errorf("wrapped: %w", outlined()), stacktrace.Callers(0))
+}
+
+type worker struct{}
+
+//go:noinline
+func (*worker) run() error {
+	return <-stacktrace.Go(func() error {
+		return stacktrace.Errorf("in goroutine: %w", os.ErrNotExist)
+	})
+}
+
+func main() {
+	err := errors.Join(outlined(), wrapped(), new(worker).run())
+	json.NewEncoder(os.Stdout).Encode(result{
+		Raw:  stacktrace.GetRawDebugInfo(err),
+		Info: stacktrace.GetDebugInfo(err),
+	})
+}
@@ -0,0 +1,74 @@
+package stacktrace
+
+import (
+	"runtime"
+	"sync"
+)
+
+// symbols caches the frames resolved from a slice of program counters.
+//
+// The frames are resolved at most once, when they are first needed, and are
+// shared by all copies of the [Error] that owns the symbols. A copy whose
+// Callers was replaced by another slice gets its frames resolved every time.
+type symbols struct {
+	once   sync.Once
+	pc     []uintptr // the program counters resolved into frames
+	frames []runtime.Frame
+}
+
+func (s *symbols) resolve(pc []uintptr) []runtime.Frame {
+	s.once.Do(func() {
+		s.pc = pc
+		s.frames = resolveFrames(pc)
+	})
+	if !sameSlice(s.pc, pc) {
+		return resolveFrames(pc)
+	}
+	return s.frames
+}
+
+// sameSlice reports whether a and b are the same slice of the same array.
+func sameSlice(a, b []uintptr) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// resolveFrames returns all the frames for pc, including inlined frames.
+func resolveFrames(pc []uintptr) []runtime.Frame {
+	if len(pc) == 0 {
+		return nil
+	}
+	list := make([]runtime.Frame, 0, len(pc))
+	frames := runtime.CallersFrames(pc)
+	for {
+		frame, more := frames.Next()
+		list = append(list, frame)
+		if !more {
+			break
+		}
+	}
+	return list
+}
+
+// frameResolver is implemented by the StackTracers that can provide their
+// frames already resolved, such as [Error].
+type frameResolver interface {
+	resolvedFrames() []runtime.Frame
+}
+
+// walkStackTracerFrames calls fn for each frame of v in the same way as
+// walkCallersFrames does, but reuses the resolved frames when v provides them.
+func walkStackTracerFrames(v StackTracer, fn func(*runtime.Frame)) {
+	r, ok := v.(frameResolver)
+	if !ok {
+		walkCallersFrames(v.StackTrace(), fn)
+		return
+	}
+	frames := r.resolvedFrames()
+	for i := range frames {
+		frame := frames[i]
+		fn(&frame)
+		if frame.Function == "main.main" {
+			break
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package stacktrace
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// TraceAllOptions controls the snapshot of the goroutines taken by
+// [TraceAllOptions.TraceAll].
+//
+// The zero value uses the defaults described for each field.
+type TraceAllOptions struct {
+	// BufferSize is the maximum size in bytes of the dump of the goroutines.
+	// The goroutines that don't fit in it are dropped.
+	// If zero or negative, 1 MiB is used.
+	BufferSize int
+
+	// MaxStacks is the maximum number of the distinct stacks kept in the
+	// snapshot. The goroutines with the other stacks are counted but dropped.
+	// If zero or negative, 32 is used.
+	MaxStacks int
+}
+
+// TraceAll returns the error along with the stack frame information of the
+// current goroutine, like [Trace], and a snapshot of the stacks of all the
+// other goroutines.
+// It returns nil if the input error is nil.
+//
+// The goroutines with the identical stack in the same state are rendered by
+// [GetDebugInfo] once, under a heading such as
+// "## 17 goroutines with this stack [chan receive]".
+//
+// TraceAll stops the world while it takes the snapshot, and it is much more
+// expensive than [Trace]. It is intended for the errors that are rare but hard
+// to diagnose, such as the ones caused by deadlocks.
+//
+// If err already has a snapshot, it is returned as is.
+//
+// This is equivalent to:
+//
+//	stacktrace.TraceAllOptions{}.TraceAll(err)
+func TraceAll(err error) error {
+	return TraceAllOptions{}.traceAll(err, 1)
+}
+
+// TraceAll works in the same way as the [TraceAll] function does, but takes
+// the snapshot according to o.
+func (o TraceAllOptions) TraceAll(err error) error {
+	return o.traceAll(err, 1)
+}
+
+func (o TraceAllOptions) traceAll(err error, skip int) error {
+	if err == nil {
+		return nil
+	}
+	err = withSkip(err, skip+1)
+	var other *goroutinesError
+	if errors.As(err, &other) {
+		return err
+	}
+	return o.snapshot(err)
+}
+
+// GoroutineSnapshot returns the goroutines captured by [TraceAll] in err's
+// chain, except the one that called TraceAll, or nil if there is none.
+//
+// The goroutines beyond [TraceAllOptions.MaxStacks] are not included.
+func GoroutineSnapshot(err error) *GoroutineDump {
+	var v *goroutinesError
+	if !errors.As(err, &v) {
+		return nil
+	}
+	d := &GoroutineDump{}
+	for _, g := range v.groups {
+		d.Goroutines = append(d.Goroutines, g.goroutines...)
+	}
+	return d
+}
+
+// goroutinesError wraps err with a snapshot of the other goroutines.
+type goroutinesError struct {
+	err    error
+	groups []*goroutineGroup
+
+	// omitted is the number of the goroutines dropped from the groups.
+	omitted int
+
+	// truncated reports whether the dump didn't fit in the buffer.
+	truncated bool
+}
+
+func (o TraceAllOptions) snapshot(err error) *goroutinesError {
+	size := o.BufferSize
+	if size <= 0 {
+		size = 1 << 20
+	}
+	maxStacks := o.MaxStacks
+	if maxStacks <= 0 {
+		maxStacks = 32
+	}
+	buf := make([]byte, size)
+	n := runtime.Stack(buf, true)
+	s := &goroutinesError{err: err, truncated: n == len(buf)}
+	d, _ := ParseGoroutineDump(bytes.NewReader(buf[:n]))
+	if d == nil || len(d.Goroutines) == 0 {
+		return s
+	}
+	index := map[string]*goroutineGroup{}
+	for _, g := range d.Goroutines[1:] { // the first one is the current goroutine
+		key := goroutineStackKey(g)
+		if group, ok := index[key]; ok {
+			group.goroutines = append(group.goroutines, g)
+		} else if len(s.groups) < maxStacks {
+			group := &goroutineGroup{goroutines: []*Goroutine{g}}
+			index[key] = group
+			s.groups = append(s.groups, group)
+		} else {
+			s.omitted++
+		}
+	}
+	return s
+}
+
+// goroutineStackKey returns the key to group the goroutines with the
+// identical stack in the same state.
+func goroutineStackKey(g *Goroutine) string {
+	var b strings.Builder
+	b.WriteString(g.State)
+	for _, frame := range g.Frames {
+		b.WriteString("\n" + frame.Function + " " + frame.File + ":" + strconv.Itoa(frame.Line))
+	}
+	if g.CreatedBy != nil {
+		b.WriteString("\ncreated by " + g.CreatedBy.Function + " " + g.CreatedBy.File + ":" + strconv.Itoa(g.CreatedBy.Line))
+	}
+	return b.String()
+}
+
+// Error returns the message of the wrapped error unchanged.
+func (err *goroutinesError) Error() string {
+	return err.err.Error()
+}
+
+func (err *goroutinesError) Unwrap() error {
+	return err.err
+}
+
+// sections returns the sections rendered after the stack traces of the error
+// chain.
+func (err *goroutinesError) sections() []stackSection {
+	var sections []stackSection
+	for _, group := range err.groups {
+		sections = append(sections, stackSection{heading: "## " + group.Error(), tracer: group})
+		if g := group.goroutines[0]; g.CreatedBy != nil {
+			sections = append(sections, stackSection{heading: "## created by", tracer: &goroutineCreator{g: g}})
+		}
+	}
+	switch {
+	case err.omitted == 1:
+		sections = append(sections, stackSection{heading: "## 1 more goroutine omitted"})
+	case err.omitted > 1:
+		sections = append(sections, stackSection{heading: "## " + strconv.Itoa(err.omitted) + " more goroutines omitted"})
+	}
+	if err.truncated {
+		sections = append(sections, stackSection{heading: "## more goroutines truncated"})
+	}
+	return sections
+}
+
+// snapshotSections returns the sections of the snapshot in err's chain taken
+// by TraceAll, if any.
+func snapshotSections(err error) []stackSection {
+	var v *goroutinesError
+	if !errors.As(err, &v) {
+		return nil
+	}
+	return v.sections()
+}
+
+// goroutineGroup is a StackTracer that holds the goroutines with the identical
+// stack in the same state.
+type goroutineGroup struct {
+	goroutines []*Goroutine
+}
+
+func (err *goroutineGroup) Error() string {
+	g := err.goroutines[0]
+	if len(err.goroutines) == 1 {
+		return g.Error()
+	}
+	return strconv.Itoa(len(err.goroutines)) + " goroutines with this stack [" + g.State + "]"
+}
+
+func (err *goroutineGroup) StackTrace() []uintptr {
+	return nil
+}
+
+func (err *goroutineGroup) resolvedFrames() []runtime.Frame {
+	return err.goroutines[0].resolvedFrames()
+}
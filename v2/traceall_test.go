@@ -0,0 +1,132 @@
+package stacktrace_test
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goaux/stacktrace/v2"
+)
+
+func TestTraceAll(t *testing.T) {
+	if stacktrace.TraceAll(nil) != nil {
+		t.Error("TraceAll(nil) must be nil")
+	}
+
+	const n = 17
+	var wg, started sync.WaitGroup
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		wg.Wait()
+	}()
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		started.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			<-done
+		}()
+	}
+	started.Wait()
+	waitForState(t, n, "chan receive")
+
+	err := stacktrace.TraceAll(os.ErrInvalid)
+	if !errors.Is(err, os.ErrInvalid) {
+		t.Errorf("errors.Is must be true: %v", err)
+	}
+	if !strings.Contains(stacktrace.Frames(err)[0].Function, "TestTraceAll") {
+		t.Errorf("the first frame must be TestTraceAll: %v", stacktrace.Frames(err))
+	}
+
+	entries := stacktrace.GetDebugInfo(err).StackEntries
+	heading := "## 17 goroutines with this stack [chan receive]"
+	i := indexOf(entries, heading)
+	if i == -1 {
+		t.Fatalf("%q not found: %q", heading, entries)
+	}
+	if !strings.Contains(entries[i+1], "TestTraceAll.func") {
+		t.Errorf("unexpected frame: %q", entries[i+1])
+	}
+
+	var count int
+	for _, g := range stacktrace.GoroutineSnapshot(err).Goroutines {
+		if g.CreatedBy != nil && strings.HasSuffix(g.CreatedBy.Function, ".TestTraceAll") {
+			count++
+		}
+	}
+	if count < n {
+		t.Errorf("count = %d, must be at least %d", count, n)
+	}
+
+	t.Run("again", func(t *testing.T) {
+		if got := stacktrace.TraceAll(err); got != err {
+			t.Errorf("got=%v want=%v", got, err)
+		}
+	})
+
+	t.Run("Trace", func(t *testing.T) {
+		if stacktrace.GoroutineSnapshot(stacktrace.Trace(os.ErrInvalid)) != nil {
+			t.Error("GoroutineSnapshot must be nil")
+		}
+	})
+
+	t.Run("GetRawDebugInfo", func(t *testing.T) {
+		got := stacktrace.GetRawDebugInfo(err).Resolve(stacktrace.FramesOf).StackEntries
+		if !reflect.DeepEqual(got, entries) {
+			t.Errorf("got=%q want=%q", got, entries)
+		}
+	})
+}
+
+func TestTraceAllOptions(t *testing.T) {
+	t.Run("MaxStacks", func(t *testing.T) {
+		err := stacktrace.TraceAllOptions{MaxStacks: 1}.TraceAll(os.ErrInvalid)
+		entries := stacktrace.GetDebugInfo(err).StackEntries
+		var headings []string
+		for _, entry := range entries {
+			if strings.HasPrefix(entry, "## ") && entry != "## created by" {
+				headings = append(headings, entry)
+			}
+		}
+		if len(headings) != 2 || !strings.HasSuffix(headings[1], " omitted") {
+			t.Errorf("unexpected headings: %q", headings)
+		}
+		if got := len(stacktrace.GoroutineSnapshot(err).Goroutines); got == 0 {
+			t.Error("the snapshot must not be empty")
+		}
+	})
+
+	t.Run("BufferSize", func(t *testing.T) {
+		err := stacktrace.TraceAllOptions{BufferSize: 100}.TraceAll(os.ErrInvalid)
+		entries := stacktrace.GetDebugInfo(err).StackEntries
+		if got, want := entries[len(entries)-1], "## more goroutines truncated"; got != want {
+			t.Errorf("got=%q want=%q", got, want)
+		}
+	})
+}
+
+// waitForState waits until at least n goroutines are in the state.
+func waitForState(t *testing.T, n int, state string) {
+	t.Helper()
+	for i := 0; i < 1000; i++ {
+		buf := make([]byte, 1<<20)
+		buf = buf[:runtime.Stack(buf, true)]
+		if strings.Count(string(buf), "["+state+"]:") >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %d goroutines in %q", n, state)
+}
+
+func ExampleTraceAll() {
+	err := stacktrace.TraceAll(os.ErrDeadlineExceeded)
+	_ = stacktrace.Format(err) // includes the stacks of the other goroutines
+}
@@ -0,0 +1,79 @@
+package stacktrace
+
+import (
+	"strconv"
+	"strings"
+)
+
+// appendTree appends the entries for the error graph rooted at err, whose
+// path is path. See [FormatOptions.Tree].
+func (b *entriesBuilder) appendTree(err error, path []int) {
+	depth := len(path)
+	switch {
+	case depth != 0:
+		b.entries = append(b.entries, treeIndent(depth-1)+treePath(path)+" "+treeMessage(err))
+	case isCreatedByError(err):
+		// The message of the root is the Detail, but it doesn't tell that the
+		// stack is the one of the creator of the goroutine.
+		b.entries = append(b.entries, "created by")
+	}
+	n := len(b.entries)
+	switch stack, createdBy := ownStacks(err); {
+	case stack == nil && createdBy != nil:
+		b.appendFrames(createdBy)
+	case stack != nil:
+		b.appendFrames(stack)
+		if createdBy != nil {
+			b.entries = append(b.entries, "created by")
+			b.appendFrames(createdBy)
+		}
+	}
+	for i := n; i < len(b.entries); i++ {
+		b.entries[i] = treeIndent(depth) + "| " + b.entries[i]
+	}
+	switch err := err.(type) {
+	case interface{ Unwrap() error }:
+		if next := err.Unwrap(); next != nil {
+			b.appendTree(next, append(path[:depth:depth], 0))
+		}
+	case interface{ Unwrap() []error }:
+		for i, next := range err.Unwrap() {
+			if next != nil {
+				b.appendTree(next, append(path[:depth:depth], i))
+			}
+		}
+	}
+}
+
+// treeMessage returns the message of err in a single line.
+// The message of the error holding the stack of the creator of a
+// goroutine is "created by".
+func treeMessage(err error) string {
+	if isCreatedByError(err) {
+		return "created by"
+	}
+	return strings.ReplaceAll(err.Error(), "\n", "; ")
+}
+
+// treePath returns path in the form of "[0.1]".
+func treePath(path []int) string {
+	s := make([]string, len(path))
+	for i, n := range path {
+		s[i] = strconv.Itoa(n)
+	}
+	return "[" + strings.Join(s, ".") + "]"
+}
+
+func treeIndent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// isCreatedByError reports whether err holds only the stack of the creator
+// of a goroutine.
+func isCreatedByError(err error) bool {
+	stack, createdBy := ownStacks(err)
+	if stack == nil {
+		return createdBy != nil
+	}
+	return isCreatedBy(stack)
+}
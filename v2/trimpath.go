@@ -0,0 +1,70 @@
+package stacktrace
+
+import (
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// TrimmedFile returns the file name of the frame rewritten into a form that
+// doesn't depend on the machine where the program was built:
+//
+//   - the files in GOROOT: "$GOROOT/src/net/http/server.go"
+//   - the files in the module cache: "github.com/foo/bar@v1.2.3/x.go"
+//   - the files in the main module: "example.com/hello/run.go"
+//   - the files in GOPATH: "github.com/foo/bar/x.go"
+//
+// The main module and the package main are identified by
+// [runtime/debug.ReadBuildInfo].
+// If the form cannot be determined, the file name is returned unchanged.
+func (frame Frame) TrimmedFile() string {
+	return trimFile(frame.File, frame.Package)
+}
+
+// trimFile rewrites file, which contains a function of the package pkg,
+// as described in Frame.TrimmedFile.
+func trimFile(file, pkg string) string {
+	return trimModuleFile(file, pkg, mainModulePath(), mainPackagePath())
+}
+
+// trimModuleFile is trimFile with the module path of the main module mod and
+// the import path of the package main mainPkg.
+func trimModuleFile(file, pkg, mod, mainPkg string) string {
+	if file == "" {
+		return file
+	}
+	file = filepath.ToSlash(file)
+	dir, base := path.Split(file)
+	dir = strings.TrimSuffix(dir, "/")
+	pkg = strings.TrimSuffix(pkg, "_test")
+	if pkg == "main" && mainPkg != "" {
+		pkg = mainPkg
+	}
+
+	if goroot := runtimeGOROOT(); goroot != "" {
+		if rest, ok := strings.CutPrefix(file, goroot+"/src/"); ok {
+			return "$GOROOT/src/" + rest
+		}
+	}
+	if isStdlibPackage(pkg) && strings.HasSuffix(dir, "/src/"+pkg) {
+		return "$GOROOT/src/" + pkg + "/" + base
+	}
+	if i := strings.LastIndex(file, "/pkg/mod/"); i != -1 {
+		return file[i+len("/pkg/mod/"):]
+	}
+	if inModule(pkg, mod) {
+		rel := pkg[len(mod):]
+		if strings.HasSuffix(dir, rel) {
+			return mod + rel + "/" + base
+		}
+	}
+	if pkg != "" && pkg != "main" && strings.HasSuffix(dir, "/"+pkg) {
+		return pkg + "/" + base
+	}
+	return file
+}
+
+func runtimeGOROOT() string {
+	return filepath.ToSlash(runtime.GOROOT())
+}
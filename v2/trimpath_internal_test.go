@@ -0,0 +1,37 @@
+package stacktrace
+
+import "testing"
+
+func TestTrimFile(t *testing.T) {
+	goroot := runtimeGOROOT()
+	const mod = "example.com/hello"
+	const mainPkg = mod + "/cmd/hello"
+	tests := []struct {
+		file string
+		pkg  string
+		want string
+	}{
+		{goroot + "/src/net/http/server.go", "net/http", "$GOROOT/src/net/http/server.go"},
+		{"/opt/go1.99/src/net/http/server.go", "net/http", "$GOROOT/src/net/http/server.go"},
+		{"/opt/go1.99/src/vendor/golang.org/x/net/http2/frame.go", "vendor/golang.org/x/net/http2", "$GOROOT/src/vendor/golang.org/x/net/http2/frame.go"},
+		{"/home/builder/go/pkg/mod/github.com/foo/bar@v1.2.3/x.go", "github.com/foo/bar", "github.com/foo/bar@v1.2.3/x.go"},
+		{"/home/builder/go/pkg/mod/github.com/foo/bar@v1.2.3/sub/x.go", "github.com/foo/bar/sub", "github.com/foo/bar@v1.2.3/sub/x.go"},
+		{"/home/builder/go/src/github.com/foo/bar/x.go", "github.com/foo/bar", "github.com/foo/bar/x.go"},
+		{"/build/src/hello/x.go", mod, mod + "/x.go"},
+		{"/build/src/hello/x_test.go", mod + "_test", mod + "/x_test.go"},
+		{"/build/src/hello/greet/x.go", mod + "/greet", mod + "/greet/x.go"},
+		{"/build/src/hello/cmd/hello/main.go", "main", mod + "/cmd/hello/main.go"},
+		{"/elsewhere/main.go", "main", "/elsewhere/main.go"},
+		{"/build/src/hello/cmd/other/main.go", mod + "/cmd/other", mod + "/cmd/other/main.go"},
+		{"/build/src/hello/x.go", mod + "/other", "/build/src/hello/x.go"},
+		{"/unknown/x.go", "github.com/foo/bar", "/unknown/x.go"},
+		{"", "github.com/foo/bar", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if got := trimModuleFile(tt.file, tt.pkg, mod, mainPkg); got != tt.want {
+				t.Errorf("got=%q want=%q", got, tt.want)
+			}
+		})
+	}
+}